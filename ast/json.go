@@ -0,0 +1,441 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/antonmedv/expr/file"
+)
+
+// jsonNode is the JSON form of all nodes. Kind is the name of the node type
+// without the Node suffix, e.g. "Binary" for BinaryNode. The other fields are
+// named after the fields of the node and are omitted when empty.
+type jsonNode struct {
+	Kind       string
+	Location   file.Location
+	Type       string          `json:",omitempty"` // Resolved type, if the tree is checked.
+	Value      json.RawMessage `json:",omitempty"`
+	ValueType  string          `json:",omitempty"` // Type of the constant: "int", "float", "string", "bool", "duration" or "time".
+	Operator   string          `json:",omitempty"`
+	Namespace  string          `json:",omitempty"`
+	Name       string          `json:",omitempty"`
+	Optional   bool            `json:",omitempty"`
+	Params     []string        `json:",omitempty"`
+	Node       *jsonNode       `json:",omitempty"`
+	Left       *jsonNode       `json:",omitempty"`
+	Right      *jsonNode       `json:",omitempty"`
+	Property   *jsonNode       `json:",omitempty"`
+	From       *jsonNode       `json:",omitempty"`
+	To         *jsonNode       `json:",omitempty"`
+	Step       *jsonNode       `json:",omitempty"`
+	Callee     *jsonNode       `json:",omitempty"`
+	Arguments  *[]*jsonNode    `json:",omitempty"` // Nil for constants of builtin namespaces.
+	Parts      []*jsonNode     `json:",omitempty"`
+	Nodes      []*jsonNode     `json:",omitempty"`
+	Pairs      []*jsonNode     `json:",omitempty"`
+	Key        *jsonNode       `json:",omitempty"`
+	Expr       *jsonNode       `json:",omitempty"`
+	Cases      []*jsonNode     `json:",omitempty"`
+	Default    *jsonNode       `json:",omitempty"`
+	Conditions []*jsonNode     `json:",omitempty"`
+	Cond       *jsonNode       `json:",omitempty"`
+	Exp1       *jsonNode       `json:",omitempty"` // Nil for a ?: b, where it is Cond.
+	Exp2       *jsonNode       `json:",omitempty"`
+}
+
+// Marshal returns the JSON form of the tree. Only the syntax, locations and
+// types are written, everything else is filled by the checker again.
+func Marshal(node Node) ([]byte, error) {
+	e := &encoder{}
+	j := e.encode(node)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return json.Marshal(j)
+}
+
+// Unmarshal parses the JSON form of the tree. Types are not restored, as
+// they are resolved by the checker again.
+func Unmarshal(data []byte) (Node, error) {
+	var j *jsonNode
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	d := &decoder{}
+	node := d.decode(j, "tree")
+	if d.err != nil {
+		return nil, d.err
+	}
+	return node, nil
+}
+
+type encoder struct {
+	err error
+}
+
+func (e *encoder) encode(node Node) *jsonNode {
+	if node == nil || e.err != nil {
+		return nil
+	}
+	j := &jsonNode{
+		Kind:     kindOf(node),
+		Location: node.Location(),
+	}
+	if t := node.Type(); t != nil {
+		j.Type = t.String()
+	}
+
+	switch n := node.(type) {
+	case *NilNode:
+	case *IdentifierNode:
+		j.Value = e.value(n.Value)
+	case *IntegerNode:
+		j.Value = e.value(n.Value)
+	case *FloatNode:
+		j.Value = e.value(n.Value)
+	case *BoolNode:
+		j.Value = e.value(n.Value)
+	case *StringNode:
+		j.Value = e.value(n.Value)
+	case *TemplateNode:
+		j.Parts = e.list(n.Parts)
+	case *ConstantNode:
+		switch v := n.Value.(type) {
+		case nil:
+		case time.Duration:
+			j.ValueType = "duration"
+			j.Value = e.value(v.String())
+		case time.Time:
+			j.ValueType = "time"
+			j.Value = e.value(v.Format(time.RFC3339Nano))
+		case int:
+			j.ValueType = "int"
+			j.Value = e.value(v)
+		case float64:
+			j.ValueType = "float"
+			j.Value = e.value(v)
+		case string:
+			j.ValueType = "string"
+			j.Value = e.value(v)
+		case bool:
+			j.ValueType = "bool"
+			j.Value = e.value(v)
+		default:
+			e.err = fmt.Errorf("cannot marshal constant of type %T", v)
+			return nil
+		}
+	case *UnaryNode:
+		j.Operator = n.Operator
+		j.Node = e.encode(n.Node)
+	case *BinaryNode:
+		j.Operator = n.Operator
+		j.Left = e.encode(n.Left)
+		j.Right = e.encode(n.Right)
+	case *ChainNode:
+		j.Node = e.encode(n.Node)
+	case *MemberNode:
+		j.Node = e.encode(n.Node)
+		j.Property = e.encode(n.Property)
+		j.Optional = n.Optional
+	case *SliceNode:
+		j.Node = e.encode(n.Node)
+		j.From = e.encode(n.From)
+		j.To = e.encode(n.To)
+		j.Step = e.encode(n.Step)
+	case *CallNode:
+		j.Callee = e.encode(n.Callee)
+		arguments := e.list(n.Arguments)
+		j.Arguments = &arguments
+		j.Optional = n.Optional
+	case *BuiltinNode:
+		j.Namespace = n.Namespace
+		j.Name = n.Name
+		if n.Arguments != nil {
+			arguments := e.list(n.Arguments)
+			j.Arguments = &arguments
+		}
+	case *ClosureNode:
+		j.Params = n.Params
+		j.Node = e.encode(n.Node)
+	case *PointerNode:
+		j.Name = n.Name
+	case *VariableDeclaratorNode:
+		j.Name = n.Name
+		j.Value = e.node(n.Value)
+		j.Expr = e.encode(n.Expr)
+	case *SwitchNode:
+		j.Node = e.encode(n.Node)
+		j.Cases = e.list(n.Cases)
+		j.Default = e.encode(n.Default)
+	case *CaseNode:
+		j.Conditions = e.list(n.Conditions)
+		j.Expr = e.encode(n.Expr)
+	case *ConditionalNode:
+		j.Cond = e.encode(n.Cond)
+		if n.Exp1 != n.Cond { // Otherwise, it is a ?: b.
+			j.Exp1 = e.encode(n.Exp1)
+		}
+		j.Exp2 = e.encode(n.Exp2)
+	case *ArrayNode:
+		j.Nodes = e.list(n.Nodes)
+	case *MapNode:
+		j.Pairs = e.list(n.Pairs)
+	case *PairNode:
+		j.Key = e.encode(n.Key)
+		j.Value = e.node(n.Value)
+	case *SpreadNode:
+		j.Node = e.encode(n.Node)
+	default:
+		e.err = fmt.Errorf("cannot marshal node of type %T", node)
+	}
+	return j
+}
+
+func (e *encoder) list(nodes []Node) []*jsonNode {
+	list := make([]*jsonNode, len(nodes))
+	for i, node := range nodes {
+		list[i] = e.encode(node)
+	}
+	return list
+}
+
+// node encodes the node, which is stored in the Value field.
+func (e *encoder) node(node Node) json.RawMessage {
+	return e.value(e.encode(node))
+}
+
+func (e *encoder) value(v interface{}) json.RawMessage {
+	if e.err != nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+	}
+	return b
+}
+
+func kindOf(node Node) string {
+	t := reflect.TypeOf(node)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := t.Name()
+	return name[:len(name)-len("Node")]
+}
+
+type decoder struct {
+	err error
+}
+
+func (d *decoder) decode(j *jsonNode, field string) Node {
+	if d.err != nil {
+		return nil
+	}
+	if j == nil {
+		d.err = fmt.Errorf("missing %v", field)
+		return nil
+	}
+
+	var node Node
+	switch j.Kind {
+	case "Nil":
+		node = &NilNode{}
+	case "Identifier":
+		n := &IdentifierNode{}
+		d.value(j, &n.Value)
+		node = n
+	case "Integer":
+		n := &IntegerNode{}
+		d.value(j, &n.Value)
+		node = n
+	case "Float":
+		n := &FloatNode{}
+		d.value(j, &n.Value)
+		node = n
+	case "Bool":
+		n := &BoolNode{}
+		d.value(j, &n.Value)
+		node = n
+	case "String":
+		n := &StringNode{}
+		d.value(j, &n.Value)
+		node = n
+	case "Template":
+		node = &TemplateNode{Parts: d.list(j.Parts, "Template.Parts")}
+	case "Constant":
+		node = &ConstantNode{Value: d.constant(j)}
+	case "Unary":
+		node = &UnaryNode{
+			Operator: j.Operator,
+			Node:     d.decode(j.Node, "Unary.Node"),
+		}
+	case "Binary":
+		node = &BinaryNode{
+			Operator: j.Operator,
+			Left:     d.decode(j.Left, "Binary.Left"),
+			Right:    d.decode(j.Right, "Binary.Right"),
+		}
+	case "Chain":
+		node = &ChainNode{Node: d.decode(j.Node, "Chain.Node")}
+	case "Member":
+		node = &MemberNode{
+			Node:     d.decode(j.Node, "Member.Node"),
+			Property: d.decode(j.Property, "Member.Property"),
+			Optional: j.Optional,
+		}
+	case "Slice":
+		node = &SliceNode{
+			Node: d.decode(j.Node, "Slice.Node"),
+			From: d.optional(j.From, "Slice.From"),
+			To:   d.optional(j.To, "Slice.To"),
+			Step: d.optional(j.Step, "Slice.Step"),
+		}
+	case "Call":
+		n := &CallNode{
+			Callee:   d.decode(j.Callee, "Call.Callee"),
+			Optional: j.Optional,
+		}
+		if j.Arguments != nil {
+			n.Arguments = d.list(*j.Arguments, "Call.Arguments")
+		}
+		node = n
+	case "Builtin":
+		n := &BuiltinNode{
+			Namespace: j.Namespace,
+			Name:      j.Name,
+		}
+		if j.Arguments != nil {
+			n.Arguments = d.list(*j.Arguments, "Builtin.Arguments")
+		}
+		node = n
+	case "Closure":
+		node = &ClosureNode{
+			Params: j.Params,
+			Node:   d.decode(j.Node, "Closure.Node"),
+		}
+	case "Pointer":
+		node = &PointerNode{Name: j.Name}
+	case "VariableDeclarator":
+		node = &VariableDeclaratorNode{
+			Name:  j.Name,
+			Value: d.node(j.Value, "VariableDeclarator.Value"),
+			Expr:  d.decode(j.Expr, "VariableDeclarator.Expr"),
+		}
+	case "Switch":
+		node = &SwitchNode{
+			Node:    d.decode(j.Node, "Switch.Node"),
+			Cases:   d.list(j.Cases, "Switch.Cases"),
+			Default: d.optional(j.Default, "Switch.Default"),
+		}
+	case "Case":
+		node = &CaseNode{
+			Conditions: d.list(j.Conditions, "Case.Conditions"),
+			Expr:       d.decode(j.Expr, "Case.Expr"),
+		}
+	case "Conditional":
+		n := &ConditionalNode{
+			Cond: d.decode(j.Cond, "Conditional.Cond"),
+			Exp1: d.optional(j.Exp1, "Conditional.Exp1"),
+			Exp2: d.decode(j.Exp2, "Conditional.Exp2"),
+		}
+		if n.Exp1 == nil {
+			n.Exp1 = n.Cond
+		}
+		node = n
+	case "Array":
+		node = &ArrayNode{Nodes: d.list(j.Nodes, "Array.Nodes")}
+	case "Map":
+		node = &MapNode{Pairs: d.list(j.Pairs, "Map.Pairs")}
+	case "Pair":
+		node = &PairNode{
+			Key:   d.decode(j.Key, "Pair.Key"),
+			Value: d.node(j.Value, "Pair.Value"),
+		}
+	case "Spread":
+		node = &SpreadNode{Node: d.decode(j.Node, "Spread.Node")}
+	default:
+		d.err = fmt.Errorf("unknown node kind %q", j.Kind)
+		return nil
+	}
+	node.SetLocation(j.Location)
+	return node
+}
+
+func (d *decoder) optional(j *jsonNode, field string) Node {
+	if j == nil {
+		return nil
+	}
+	return d.decode(j, field)
+}
+
+func (d *decoder) list(list []*jsonNode, field string) []Node {
+	nodes := make([]Node, len(list))
+	for i, j := range list {
+		nodes[i] = d.decode(j, field)
+	}
+	return nodes
+}
+
+// node decodes the node, which is stored in the Value field.
+func (d *decoder) node(raw json.RawMessage, field string) Node {
+	var j *jsonNode
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &j); err != nil && d.err == nil {
+			d.err = err
+		}
+	}
+	return d.decode(j, field)
+}
+
+func (d *decoder) value(j *jsonNode, v interface{}) {
+	if d.err != nil {
+		return
+	}
+	if len(j.Value) == 0 {
+		d.err = fmt.Errorf("missing %v.Value", j.Kind)
+		return
+	}
+	d.err = json.Unmarshal(j.Value, v)
+}
+
+func (d *decoder) constant(j *jsonNode) interface{} {
+	var v interface{}
+	switch j.ValueType {
+	case "":
+		return nil
+	case "duration", "time":
+		var s string
+		d.value(j, &s)
+		if d.err != nil {
+			return nil
+		}
+		if j.ValueType == "duration" {
+			v, d.err = time.ParseDuration(s)
+		} else {
+			v, d.err = time.Parse(time.RFC3339Nano, s)
+		}
+		return v
+	case "int":
+		var i int
+		d.value(j, &i)
+		v = i
+	case "float":
+		var f float64
+		d.value(j, &f)
+		v = f
+	case "string":
+		var s string
+		d.value(j, &s)
+		v = s
+	case "bool":
+		var b bool
+		d.value(j, &b)
+		v = b
+	default:
+		d.err = fmt.Errorf("unknown constant value type %q", j.ValueType)
+	}
+	return v
+}
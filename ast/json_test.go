@@ -0,0 +1,105 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/checker"
+	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []string{
+		`nil`,
+		`a + 1 * 2.5`,
+		`not (a in [true, false]) && b matches "^b"`,
+		"`a ${b} c`",
+		`1h30m + now() - d"2023-01-02" - t"2023-01-02T10:00:00+01:00"`,
+		`a?.b.c[0]?.["d"]`,
+		`a.b(1, xs...)`,
+		`a?.(1)`,
+		`a[1:-1:2] + a[:]`,
+		`{a: 1, (b): 2, ...c}`,
+		`map(a, {.b + #}) + filter(a, (x, i) => x > i)`,
+		`reduce(a, {#acc + #}, 0)`,
+		`math.pi + math.abs(-1)`,
+		`let x = 1; x > 0 ? x : -x`,
+		`a ?: b`,
+		`switch x { case 1, 2 => a; case 3..5 => b; case matches "c" => c; default => d }`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			tree, err := parser.Parse(input)
+			require.NoError(t, err)
+
+			b, err := ast.Marshal(tree.Node)
+			require.NoError(t, err)
+
+			node, err := ast.Unmarshal(b)
+			require.NoError(t, err)
+			assert.Equal(t, ast.Dump(tree.Node), ast.Dump(node))
+			assert.Equal(t, ast.Print(tree.Node), ast.Print(node))
+			assert.Equal(t, tree.Node.Location(), node.Location())
+		})
+	}
+}
+
+func TestMarshal_form(t *testing.T) {
+	tree, err := parser.Parse(`a + 1h`)
+	require.NoError(t, err)
+	_, err = checker.Check(tree, nil)
+	require.NoError(t, err)
+
+	b, err := ast.Marshal(tree.Node)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Kind": "Binary",
+		"Location": {"Line": 1, "Column": 2, "Offset": 2, "EndLine": 1, "EndColumn": 3},
+		"Type": "time.Time",
+		"Operator": "+",
+		"Left": {
+			"Kind": "Identifier",
+			"Location": {"Line": 1, "Column": 0, "Offset": 0, "EndLine": 1, "EndColumn": 1},
+			"Type": "interface {}",
+			"Value": "a"
+		},
+		"Right": {
+			"Kind": "Constant",
+			"Location": {"Line": 1, "Column": 4, "Offset": 4, "EndLine": 1, "EndColumn": 6},
+			"Type": "time.Duration",
+			"Value": "1h0m0s",
+			"ValueType": "duration"
+		}
+	}`, string(b))
+
+	node, err := ast.Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, file.Location{Line: 1, Column: 4, Offset: 4, EndLine: 1, EndColumn: 6}, node.(*ast.BinaryNode).Right.Location())
+	assert.Nil(t, node.Type())
+}
+
+func TestMarshal_error(t *testing.T) {
+	_, err := ast.Marshal(&ast.ConstantNode{Value: []int{1}})
+	assert.EqualError(t, err, "cannot marshal constant of type []int")
+
+	errorTests := []struct {
+		input string
+		err   string
+	}{
+		{`null`, `missing tree`},
+		{`{"Kind": "Foo"}`, `unknown node kind "Foo"`},
+		{`{"Kind": "Binary", "Operator": "+", "Left": {"Kind": "Nil"}}`, `missing Binary.Right`},
+		{`{"Kind": "Pair", "Key": {"Kind": "Nil"}}`, `missing Pair.Value`},
+		{`{"Kind": "Integer"}`, `missing Integer.Value`},
+		{`{"Kind": "Integer", "Value": "1"}`, `json: cannot unmarshal string into Go value of type int`},
+		{`{"Kind": "Constant", "Value": 1, "ValueType": "decimal"}`, `unknown constant value type "decimal"`},
+	}
+	for _, tt := range errorTests {
+		_, err := ast.Unmarshal([]byte(tt.input))
+		assert.EqualError(t, err, tt.err, tt.input)
+	}
+}
@@ -75,6 +75,13 @@ type StringNode struct {
 	Value string
 }
 
+// TemplateNode is a template literal `text ${expr} text`. Parts are
+// StringNode for the text and arbitrary nodes for the interpolations.
+type TemplateNode struct {
+	base
+	Parts []Node
+}
+
 type ConstantNode struct {
 	base
 	Value interface{}
@@ -111,11 +118,13 @@ type MemberNode struct {
 	MethodIndex int
 }
 
+// SliceNode is Node[From:To:Step]. All of From, To and Step are optional.
 type SliceNode struct {
 	base
 	Node Node
 	From Node
 	To   Node
+	Step Node
 }
 
 type CallNode struct {
@@ -124,6 +133,7 @@ type CallNode struct {
 	Arguments []Node
 	Typed     int
 	Fast      bool
+	Optional  bool
 }
 
 type BuiltinNode struct {
@@ -143,11 +153,44 @@ func (b BuiltinNode) String() string {
 
 type ClosureNode struct {
 	base
-	Node Node
+	Params []string // Names of the element and the index, if the closure is declared as x => ... or (x, i) => ...
+	Node   Node
 }
 
 type PointerNode struct {
 	base
+	Name string // Empty for the current element (#), "acc" for the accumulator (#acc).
+}
+
+// VariableDeclaratorNode binds the value to the name in the expression:
+// let name = value; expr
+type VariableDeclaratorNode struct {
+	base
+	Name  string
+	Value Node
+	Expr  Node
+}
+
+// SwitchSubject is the name of the variable, which holds the value of the
+// switch subject in the conditions of its cases. It can't be written in the
+// source, so it never clashes with other names.
+const SwitchSubject = "#switch"
+
+// SwitchNode is a switch expression:
+// switch x { case 1, 2 => a; case 3..5 => b; default => c }
+type SwitchNode struct {
+	base
+	Node    Node
+	Cases   []Node
+	Default Node // Nil if there is no default case.
+}
+
+// CaseNode is a case of the switch expression. Conditions compare the
+// switch subject with the values of the case, e.g. #switch == 1.
+type CaseNode struct {
+	base
+	Conditions []Node
+	Expr       Node
 }
 
 type ConditionalNode struct {
@@ -172,3 +215,10 @@ type PairNode struct {
 	Key   Node
 	Value Node
 }
+
+// SpreadNode is ...Node inside of an array or a map literal, or Node...
+// as the last argument of a call.
+type SpreadNode struct {
+	base
+	Node Node
+}
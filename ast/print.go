@@ -2,8 +2,17 @@ package ast
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antonmedv/expr/parser/operator"
 )
 
 func Dump(node Node) string {
@@ -57,3 +66,399 @@ var isCapital = regexp.MustCompile("^[A-Z]")
 func isPrivate(s string) bool {
 	return !isCapital.Match([]byte(s))
 }
+
+// Print returns the source of the node in the canonical form with minimal
+// parentheses. The source parses back to an equivalent tree.
+func Print(node Node) string {
+	switch n := node.(type) {
+	case *NilNode:
+		return "nil"
+	case *IdentifierNode:
+		return n.Value
+	case *IntegerNode:
+		return strconv.Itoa(n.Value)
+	case *FloatNode:
+		return printFloat(n.Value)
+	case *BoolNode:
+		return strconv.FormatBool(n.Value)
+	case *StringNode:
+		return strconv.Quote(n.Value)
+	case *TemplateNode:
+		return printTemplate(n)
+	case *ConstantNode:
+		return printConstant(n.Value)
+	case *UnaryNode:
+		return printUnary(n)
+	case *BinaryNode:
+		return printBinary(n.Operator, n.Left, n.Right)
+	case *ChainNode:
+		return Print(n.Node)
+	case *MemberNode:
+		return printMember(n)
+	case *SliceNode:
+		out := postfix(n.Node) + "["
+		if n.From != nil {
+			out += Print(n.From)
+		}
+		out += ":"
+		if n.To != nil {
+			out += Print(n.To)
+		}
+		if n.Step != nil {
+			out += ":" + Print(n.Step)
+		}
+		return out + "]"
+	case *CallNode:
+		if n.Optional {
+			return postfix(n.Callee) + "?.(" + printArguments(n.Arguments) + ")"
+		}
+		return postfix(n.Callee) + "(" + printArguments(n.Arguments) + ")"
+	case *BuiltinNode:
+		if n.Arguments == nil {
+			return n.String() // Constant of the namespace, e.g. math.pi.
+		}
+		return n.String() + "(" + printArguments(n.Arguments) + ")"
+	case *ClosureNode:
+		switch len(n.Params) {
+		case 0:
+			return "{" + Print(n.Node) + "}"
+		case 1:
+			return n.Params[0] + " => " + Print(n.Node)
+		}
+		return "(" + strings.Join(n.Params, ", ") + ") => " + Print(n.Node)
+	case *PointerNode:
+		return "#" + n.Name
+	case *VariableDeclaratorNode:
+		return "let " + n.Name + " = " + Print(n.Value) + "; " + Print(n.Expr)
+	case *SwitchNode:
+		return printSwitch(n)
+	case *CaseNode:
+		conditions := make([]string, len(n.Conditions))
+		for i, cond := range n.Conditions {
+			conditions[i] = printCaseCondition(cond)
+		}
+		return "case " + strings.Join(conditions, ", ") + " => " + Print(n.Expr)
+	case *ConditionalNode:
+		cond := Print(n.Cond)
+		switch n.Cond.(type) {
+		case *ConditionalNode, *VariableDeclaratorNode:
+			cond = "(" + cond + ")"
+		}
+		if n.Exp1 == n.Cond {
+			return cond + " ?: " + Print(n.Exp2)
+		}
+		return cond + " ? " + Print(n.Exp1) + " : " + Print(n.Exp2)
+	case *ArrayNode:
+		return "[" + printList(n.Nodes) + "]"
+	case *MapNode:
+		return "{" + printList(n.Pairs) + "}"
+	case *PairNode:
+		return printKey(n.Key) + ": " + Print(n.Value)
+	case *SpreadNode:
+		return "..." + Print(n.Node)
+	default:
+		panic(fmt.Sprintf("undefined node type (%T)", node))
+	}
+}
+
+// negatable are operators, which are printed negated as "a not in b".
+var negatable = map[string]bool{
+	"in":         true,
+	"matches":    true,
+	"contains":   true,
+	"startsWith": true,
+	"endsWith":   true,
+}
+
+// binaryOf returns the binary operator of the node, which is printed as
+// a binary expression, including negated ones like "a not in b".
+func binaryOf(node Node) (operator.Operator, bool) {
+	switch n := node.(type) {
+	case *BinaryNode:
+		op, ok := operator.Binary[n.Operator]
+		return op, ok
+	case *UnaryNode:
+		if b, ok := negated(n); ok {
+			return operator.Binary[b.Operator], true
+		}
+	}
+	return operator.Operator{}, false
+}
+
+// unaryOf returns the precedence of the node, which is printed as a unary
+// expression, including negative numbers.
+func unaryOf(node Node) (int, bool) {
+	switch n := node.(type) {
+	case *UnaryNode:
+		if _, ok := negated(n); !ok {
+			return operator.Unary[n.Operator].Precedence, true
+		}
+	case *IntegerNode:
+		return operator.Unary["-"].Precedence, n.Value < 0
+	case *FloatNode:
+		return operator.Unary["-"].Precedence, n.Value < 0 || math.Signbit(n.Value)
+	case *ConstantNode:
+		if d, ok := n.Value.(time.Duration); ok && d < 0 {
+			return operator.Unary["-"].Precedence, true
+		}
+	}
+	return 0, false
+}
+
+func negated(n *UnaryNode) (*BinaryNode, bool) {
+	if n.Operator != "not" {
+		return nil, false
+	}
+	b, ok := n.Node.(*BinaryNode)
+	return b, ok && negatable[b.Operator]
+}
+
+func printUnary(n *UnaryNode) string {
+	if b, ok := negated(n); ok {
+		return printBinary("not "+b.Operator, b.Left, b.Right)
+	}
+	precedence := operator.Unary[n.Operator].Precedence
+	out := Print(n.Node)
+	if op, ok := binaryOf(n.Node); ok && op.Precedence < precedence || isStatement(n.Node) {
+		out = "(" + out + ")"
+	}
+	if n.Operator == "not" {
+		return "not " + out
+	}
+	return n.Operator + out
+}
+
+func printBinary(name string, left, right Node) string {
+	op := operator.Binary[strings.TrimPrefix(name, "not ")]
+	l, r := operand(left, op, false), operand(right, op, true)
+	if name == ".." {
+		if strings.HasPrefix(r, ".") {
+			return l + " .. " + r
+		}
+		return l + ".." + r
+	}
+	return l + " " + name + " " + r
+}
+
+// operand prints the node as the operand of the binary operator, with
+// parentheses where the parser would group it otherwise.
+func operand(node Node, op operator.Operator, right bool) string {
+	out := Print(node)
+	if o, ok := binaryOf(node); ok {
+		if o.Precedence < op.Precedence || o.Precedence == op.Precedence && right == (op.Associativity == operator.Left) {
+			return "(" + out + ")"
+		}
+		return out
+	}
+	if precedence, ok := unaryOf(node); ok {
+		// The operand of unary takes the following binary operators with
+		// precedence higher than its own.
+		if precedence < op.Precedence || !right && precedence == op.Precedence {
+			return "(" + out + ")"
+		}
+		return out
+	}
+	if isStatement(node) {
+		return "(" + out + ")"
+	}
+	return out
+}
+
+// isStatement reports whether the node extends to the end of expression,
+// so it must be in parentheses when it is a part of another one.
+func isStatement(node Node) bool {
+	switch node.(type) {
+	case *ConditionalNode, *VariableDeclaratorNode:
+		return true
+	}
+	return false
+}
+
+// postfix prints the node, which is followed by a member access, a slice
+// or a call.
+func postfix(node Node) string {
+	switch node.(type) {
+	case *IdentifierNode, *MemberNode, *SliceNode, *CallNode, *BuiltinNode, *ChainNode,
+		*PointerNode, *ArrayNode, *MapNode, *TemplateNode, *SwitchNode:
+		return Print(node)
+	}
+	return "(" + Print(node) + ")"
+}
+
+func printMember(n *MemberNode) string {
+	if name, ok := n.Property.(*StringNode); ok && isIdentifier(name.Value) {
+		if p, ok := n.Node.(*PointerNode); ok && p.Name == "" && !n.Optional {
+			return "." + name.Value // Short form of #.name.
+		}
+		if n.Optional {
+			return postfix(n.Node) + "?." + name.Value
+		}
+		return postfix(n.Node) + "." + name.Value
+	}
+	if n.Optional {
+		return postfix(n.Node) + "?.[" + Print(n.Property) + "]"
+	}
+	return postfix(n.Node) + "[" + Print(n.Property) + "]"
+}
+
+func printArguments(nodes []Node) string {
+	args := make([]string, len(nodes))
+	for i, node := range nodes {
+		if spread, ok := node.(*SpreadNode); ok {
+			args[i] = Print(spread.Node) + "..."
+		} else {
+			args[i] = Print(node)
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+func printList(nodes []Node) string {
+	items := make([]string, len(nodes))
+	for i, node := range nodes {
+		items[i] = Print(node)
+	}
+	return strings.Join(items, ", ")
+}
+
+func printKey(key Node) string {
+	if s, ok := key.(*StringNode); ok {
+		if _, isOperator := operator.Binary[s.Value]; isIdentifier(s.Value) && !isOperator && s.Value != "not" {
+			return s.Value
+		}
+		return strconv.Quote(s.Value)
+	}
+	return "(" + Print(key) + ")"
+}
+
+func printTemplate(n *TemplateNode) string {
+	out := "`"
+	for _, part := range n.Parts {
+		if s, ok := part.(*StringNode); ok {
+			text := strconv.Quote(s.Value)
+			text = text[1 : len(text)-1]
+			text = strings.Replace(text, "`", "\\`", -1)
+			text = strings.Replace(text, "${", "\\${", -1)
+			out += text
+		} else {
+			out += "${" + Print(part) + "}"
+		}
+	}
+	return out + "`"
+}
+
+func printSwitch(n *SwitchNode) string {
+	subject := Print(n.Node)
+	if _, ok := n.Node.(*UnaryNode); ok || strings.HasPrefix(subject, ".") || strings.HasPrefix(subject, "-") {
+		// Otherwise, switch is the identifier, e.g. switch - 1.
+		subject = "(" + subject + ")"
+	}
+	cases := make([]string, 0, len(n.Cases)+1)
+	for _, c := range n.Cases {
+		cases = append(cases, Print(c))
+	}
+	if n.Default != nil {
+		cases = append(cases, "default => "+Print(n.Default))
+	}
+	return "switch " + subject + " { " + strings.Join(cases, "; ") + " }"
+}
+
+// printCaseCondition prints the value of the case without the switch
+// subject, which the parser adds.
+func printCaseCondition(cond Node) string {
+	if b, ok := cond.(*BinaryNode); ok {
+		if subject, ok := b.Left.(*IdentifierNode); ok && subject.Value == SwitchSubject {
+			switch b.Operator {
+			case "==":
+				return Print(b.Right)
+			case "in":
+				if rng, ok := b.Right.(*BinaryNode); ok && rng.Operator == ".." {
+					return Print(b.Right)
+				}
+			case "matches":
+				return "matches " + Print(b.Right)
+			}
+		}
+	}
+	return Print(cond)
+}
+
+func printFloat(f float64) string {
+	out := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(out, ".eIN") {
+		out += ".0" // Otherwise, it is parsed as an integer.
+	}
+	return out
+}
+
+func printConstant(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		if v.Equal(v.Truncate(24*time.Hour)) && v.Location() == time.UTC {
+			return "d" + strconv.Quote(v.Format("2006-01-02"))
+		}
+		return "t" + strconv.Quote(v.Format(time.RFC3339Nano))
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return printFloat(v.Float())
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = printConstant(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]string, v.Len())
+		for _, key := range v.MapKeys() {
+			k := printKey(&StringNode{Value: fmt.Sprint(key.Interface())})
+			keys = append(keys, k)
+			values[k] = printConstant(v.MapIndex(key).Interface())
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + ": " + values[k]
+		}
+		return "{" + strings.Join(keys, ", ") + "}"
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return printConstant(v.Elem().Interface())
+	}
+	return fmt.Sprintf("%v", value) // Like decimals.
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	h, w := utf8.DecodeRuneInString(s)
+	if !isAlphabetic(h) {
+		return false
+	}
+	for _, r := range s[w:] {
+		if !isAlphabetic(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphabetic(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
@@ -0,0 +1,147 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`nil`, `nil`},
+		{`a`, `a`},
+		{`0xFF`, `255`},
+		{`1_000`, `1000`},
+		{`1.5`, `1.5`},
+		{`2.`, `2.0`},
+		{`1e21`, `1e+21`},
+		{`true`, `true`},
+		{`'str\n'`, `"str\n"`},
+		{"`a ${b + 1} \\${c} \\` d`", "`a ${b + 1} \\${c} \\` d`"},
+		{`1h30m`, `1h30m0s`},
+		{`d"2023-01-02"`, `d"2023-01-02"`},
+		{`t"2023-01-02T10:00:00Z"`, `t"2023-01-02T10:00:00Z"`},
+		{`-a`, `-a`},
+		{`- -a`, `--a`},
+		{`not a`, `not a`},
+		{`!a`, `!a`},
+		{`-(a + b)`, `-(a + b)`},
+		{`-a ** 2`, `-a ** 2`},
+		{`(-a) ** 2`, `(-a) ** 2`},
+		{`a ** -b`, `a ** (-b)`},
+		{`a * -b`, `a * -b`},
+		{`not a == b`, `not a == b`},
+		{`not (a == b)`, `not (a == b)`},
+		{`a not in b`, `a not in b`},
+		{`not (a in b)`, `a not in b`},
+		{`a not matches "x" and c`, `a not matches "x" and c`},
+		{`a == (b not in c)`, `a == (b not in c)`},
+		{`a + b * c`, `a + b * c`},
+		{`(a + b) * c`, `(a + b) * c`},
+		{`a - (b - c)`, `a - (b - c)`},
+		{`(a - b) - c`, `a - b - c`},
+		{`a ** b ** c`, `a ** b ** c`},
+		{`(a ** b) ** c`, `(a ** b) ** c`},
+		{`a or b and c`, `a or b and c`},
+		{`(a or b) and c`, `(a or b) and c`},
+		{`a ?? b ?? c`, `a ?? b ?? c`},
+		{`1..5`, `1..5`},
+		{`a | b`, `a | b`},
+		{`a | (f(b))`, `a | f(b)`},
+		{`a | f(b)`, `a | f(b)`},
+		{`a |> f(b)`, `f(a, b)`},
+		{`a ? b : c`, `a ? b : c`},
+		{`a ?: c`, `a ?: c`},
+		{`a ? b : c ? d : e`, `a ? b : c ? d : e`},
+		{`(a ? b : c) ? d : e`, `(a ? b : c) ? d : e`},
+		{`(a ? b : c) + 1`, `(a ? b : c) + 1`},
+		{`let x = 1; x + 1`, `let x = 1; x + 1`},
+		{`1 + (let x = 1; x)`, `1 + (let x = 1; x)`},
+		{`a.b.c`, `a.b.c`},
+		{`a["b"]`, `a.b`},
+		{`a["b c"]`, `a["b c"]`},
+		{`a[0]`, `a[0]`},
+		{`a[-1]`, `a[-1]`},
+		{`a?.b.c`, `a?.b.c`},
+		{`a?.["b c"]`, `a?.["b c"]`},
+		{`a?.b?.c`, `a?.b?.c`},
+		{`a?.b[0]`, `a?.b[0]`},
+		{`a.b(1, 2)`, `a.b(1, 2)`},
+		{`a?.b(1)`, `a?.b(1)`},
+		{`a?.(1)`, `a?.(1)`},
+		{`f(xs...)`, `f(xs...)`},
+		{`("str")[0]`, `("str")[0]`},
+		{`(1).a`, `(1).a`},
+		{`(a + b).c`, `(a + b).c`},
+		{`a[1:2]`, `a[1:2]`},
+		{`a[:]`, `a[:]`},
+		{`a[::-1]`, `a[::-1]`},
+		{`a[1::2]`, `a[1::2]`},
+		{`[]`, `[]`},
+		{`[1, [2, 3], ...a]`, `[1, [2, 3], ...a]`},
+		{`{}`, `{}`},
+		{`{a: 1, "b c": 2, 3: 3, (d): 4, "in": 5, ...e}`, `{a: 1, "b c": 2, "3": 3, (d): 4, "in": 5, ...e}`},
+		{`{a: 1}.a`, `{a: 1}.a`},
+		{`len(a)`, `len(a)`},
+		{`map(a, {.b + #})`, `map(a, {.b + #})`},
+		{`map(a, {#.b})`, `map(a, {.b})`},
+		{`filter(a, x => x > 1)`, `filter(a, x => x > 1)`},
+		{`map(a, (x, i) => x * i)`, `map(a, (x, i) => x * i)`},
+		{`reduce(a, {#acc + #}, 0)`, `reduce(a, {#acc + #}, 0)`},
+		{`a |> map({# * 2})`, `map(a, {# * 2})`},
+		{`math.pi`, `math.pi`},
+		{`math.abs(-1)`, `math.abs(-1)`},
+		{`strings.upper(a)`, `strings.upper(a)`},
+		{`switch x { case 1, 2 => a; case 3..5 => b; case matches "^c" => c; default => d }`, `switch x { case 1, 2 => a; case 3..5 => b; case matches "^c" => c; default => d }`},
+		{`switch (-x) { case 1 => a }`, `switch (-x) { case 1 => a }`},
+		{`switch x { case 1 => a }.b`, `switch x { case 1 => a }.b`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tree, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+
+			out := ast.Print(tree.Node)
+			assert.Equal(t, tt.expected, out)
+
+			printed, err := parser.Parse(out)
+			require.NoError(t, err)
+			assert.Equal(t, ast.Dump(tree.Node), ast.Dump(printed.Node))
+		})
+	}
+}
+
+func TestPrint_patched(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{
+			&ast.BinaryNode{
+				Operator: "**",
+				Left:     &ast.IntegerNode{Value: -2},
+				Right:    &ast.FloatNode{Value: 2},
+			},
+			`(-2) ** 2.0`,
+		},
+		{
+			&ast.BinaryNode{
+				Operator: "+",
+				Left:     &ast.ConstantNode{Value: []interface{}{1, "a", nil}},
+				Right:    &ast.ConstantNode{Value: map[string]int{"b": 2, "a c": 1}},
+			},
+			`[1, "a", nil] + {"a c": 1, b: 2}`,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ast.Print(tt.node))
+	}
+}
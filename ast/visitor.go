@@ -14,6 +14,10 @@ func Walk(node *Node, v Visitor) {
 	case *FloatNode:
 	case *BoolNode:
 	case *StringNode:
+	case *TemplateNode:
+		for i := range n.Parts {
+			Walk(&n.Parts[i], v)
+		}
 	case *ConstantNode:
 	case *UnaryNode:
 		Walk(&n.Node, v)
@@ -33,6 +37,9 @@ func Walk(node *Node, v Visitor) {
 		if n.To != nil {
 			Walk(&n.To, v)
 		}
+		if n.Step != nil {
+			Walk(&n.Step, v)
+		}
 	case *CallNode:
 		Walk(&n.Callee, v)
 		for i := range n.Arguments {
@@ -45,6 +52,22 @@ func Walk(node *Node, v Visitor) {
 	case *ClosureNode:
 		Walk(&n.Node, v)
 	case *PointerNode:
+	case *VariableDeclaratorNode:
+		Walk(&n.Value, v)
+		Walk(&n.Expr, v)
+	case *SwitchNode:
+		Walk(&n.Node, v)
+		for i := range n.Cases {
+			Walk(&n.Cases[i], v)
+		}
+		if n.Default != nil {
+			Walk(&n.Default, v)
+		}
+	case *CaseNode:
+		for i := range n.Conditions {
+			Walk(&n.Conditions[i], v)
+		}
+		Walk(&n.Expr, v)
 	case *ConditionalNode:
 		Walk(&n.Cond, v)
 		Walk(&n.Exp1, v)
@@ -60,6 +83,8 @@ func Walk(node *Node, v Visitor) {
 	case *PairNode:
 		Walk(&n.Key, v)
 		Walk(&n.Value, v)
+	case *SpreadNode:
+		Walk(&n.Node, v)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
@@ -1,5 +1,12 @@
 package builtin
 
+import (
+	"fmt"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/file"
+)
+
 type ParserArgType int
 
 const (
@@ -9,6 +16,10 @@ const (
 
 type Argument struct {
 	ParserType ParserArgType
+	// Optional arguments may be omitted, if all following arguments are omitted too.
+	Optional bool
+	// Variadic argument is the last one and can be repeated any number of times.
+	Variadic bool
 }
 
 func ParserArguments(m Function) []ParserArgType {
@@ -21,3 +32,52 @@ func ParserArguments(m Function) []ParserArgType {
 
 	return res
 }
+
+// CheckArguments reports an error, if the arguments of the node do not match
+// the arguments of the function: their number, and which of them are
+// closures. The parser ensures it for parsed expressions, trees built in
+// other ways must be checked before the type checker visits the node.
+func CheckArguments(f Function, node *ast.BuiltinNode) *file.Error {
+	name := node.Name
+	if node.Namespace != "" {
+		name = node.Namespace + "." + node.Name
+	}
+	args := f.Arguments()
+
+	required := 0
+	for _, arg := range args {
+		if !arg.Optional && !arg.Variadic {
+			required++
+		}
+	}
+	variadic := len(args) > 0 && args[len(args)-1].Variadic
+	if len(node.Arguments) < required {
+		return argumentError(node, file.ArgumentCount, "not enough arguments to call %s", name)
+	}
+	if len(node.Arguments) > len(args) && !variadic {
+		return argumentError(node, file.ArgumentCount, "too many arguments to call %s", name)
+	}
+
+	for i, arg := range node.Arguments {
+		expected := args[len(args)-1]
+		if i < len(args) {
+			expected = args[i]
+		}
+		_, closure := arg.(*ast.ClosureNode)
+		if expected.ParserType == Closure && !closure {
+			return argumentError(arg, file.TypeMismatch, "argument %d of %s must be a closure", i+1, name)
+		}
+		if expected.ParserType == Expression && closure {
+			return argumentError(arg, file.TypeMismatch, "argument %d of %s cannot be a closure", i+1, name)
+		}
+	}
+	return nil
+}
+
+func argumentError(node ast.Node, code file.ErrorCode, format string, args ...interface{}) *file.Error {
+	return &file.Error{
+		Location: node.Location(),
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
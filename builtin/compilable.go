@@ -1,5 +1,40 @@
 package builtin
 
+import (
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/vm"
+)
+
+// Emitter is the part of the compiler exposed to members writing their own bytecode.
+type Emitter interface {
+	Compile(node ast.Node)
+	Emit(op vm.Opcode, args ...int) int
+	EmitPush(value interface{}) int
+	AddConstant(constant interface{}) int
+	// EmitJump emits a jump opcode which target must be set with PatchJump.
+	EmitJump(op vm.Opcode) int
+	PatchJump(placeholder int)
+	EmitLoop(body func())
+	EmitCond(body func())
+	// CompileReducer compiles the closure of a reducer, where #acc is the
+	// accumulator of the innermost scope, also in nested closures.
+	CompileReducer(closure ast.Node)
+}
+
+// Compilable members emit bytecode for a call by themselves.
 type Compilable interface {
-	Compile()
+	Compile(c Emitter, node *ast.BuiltinNode)
+}
+
+// Runnable members are executed by the VM as a Go function,
+// which receives evaluated arguments of the call.
+type Runnable interface {
+	Run(args ...interface{}) (interface{}, error)
+}
+
+// Foldable members are Runnable members without side effects. Calls with
+// constant arguments are replaced with their result on compile step.
+type Foldable interface {
+	Runnable
+	Foldable() bool
 }
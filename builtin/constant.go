@@ -3,7 +3,7 @@ package builtin
 type BaseConstant struct {
 }
 
-func (b *BaseConstant) Invokable() bool {
+func (b *BaseConstant) Callable() bool {
 	return false
 }
 
@@ -7,6 +7,15 @@ func (b *BaseFunc) Callable() bool {
 	return true
 }
 
+// BaseFoldableFunc is a BaseFunc without side effects.
+type BaseFoldableFunc struct {
+	BaseFunc
+}
+
+func (b *BaseFoldableFunc) Foldable() bool {
+	return true
+}
+
 type Function interface {
 	Arguments() []Argument
 }
@@ -4,15 +4,12 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/file"
 	"github.com/antonmedv/expr/util/checking"
 )
 
-// TODO:
-// - parsing
-// - checking
-// - compiling
-// - execution / runtime
-
+// Member of a builtin namespace. To be compiled a member must also
+// implement either Compilable or Runnable.
 type Member interface {
 	Name() string
 	Callable() bool
@@ -32,7 +29,7 @@ func (b *BaseNamespace) Check(v *checking.ExternVisitor, node *ast.BuiltinNode)
 	m, ok := b.Get(node.Name)
 
 	if !ok {
-		v.Error(node, "%s does not exist in %s", node.Name, node.Namespace)
+		return v.ErrorCode(node, file.UnknownIdentifier, "%s does not exist in %s", node.Name, node.Namespace)
 	}
 
 	return m.Visit(v, node)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"unicode/utf8"
 
 	"github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/conf"
@@ -23,6 +24,7 @@ func Check(tree *parser.Tree, config *conf.Config) (t reflect.Type, err error) {
 
 	v := &CheckVisitor{
 		config:      config,
+		namespaces:  namespaces.With(config.Namespaces),
 		collections: make([]reflect.Type, 0),
 		parents:     make([]ast.Node, 0),
 	}
@@ -31,22 +33,22 @@ func Check(tree *parser.Tree, config *conf.Config) (t reflect.Type, err error) {
 		func(node ast.Node) (reflect.Type, checking.Info) {
 			return v.visit(node)
 		},
-		func(node ast.Node, format string, args ...interface{}) (reflect.Type, checking.Info) {
-			return v.error(node, format, args...)
+		func(node ast.Node, code file.ErrorCode, format string, args ...interface{}) (reflect.Type, checking.Info) {
+			return v.error(node, code, format, args...)
 		},
-		func(collection reflect.Type) {
-			// v.collections = append(v.collections, collection)
+		func(collection, accumulator reflect.Type) {
 			v.collections = append(v.collections, collection)
+			v.accumulators = append(v.accumulators, accumulator)
 		}, func() {
-			// v.collections = v.collections[:len(v.collections)-1]
 			v.collections = v.collections[:len(v.collections)-1]
+			v.accumulators = v.accumulators[:len(v.accumulators)-1]
 		},
 	)
 
 	t, _ = v.visit(tree.Node)
 
-	if v.err != nil {
-		return t, v.err.Bind(tree.Source)
+	if len(v.errors) > 0 {
+		return t, v.errors.Bind(tree.Source).Err()
 	}
 
 	if v.config.Expect != reflect.Invalid {
@@ -67,10 +69,22 @@ func Check(tree *parser.Tree, config *conf.Config) (t reflect.Type, err error) {
 
 type CheckVisitor struct {
 	config      *conf.Config
+	namespaces  namespaces.Table
 	collections []reflect.Type
-	parents     []ast.Node
-	err         *file.Error
-	ex          *ExternVisitor
+	// accumulators are types of #acc in closures of the collections, nil
+	// for collections, which are not reduced.
+	accumulators []reflect.Type
+	variables    []variable
+	parents      []ast.Node
+	errors       file.Errors
+	ex           *ExternVisitor
+}
+
+// variable is a named param of the closure or a let binding, visible in
+// nested nodes.
+type variable struct {
+	name string
+	t    reflect.Type
 }
 
 func (v *CheckVisitor) visit(node ast.Node) (reflect.Type, Info) {
@@ -90,6 +104,8 @@ func (v *CheckVisitor) visit(node ast.Node) (reflect.Type, Info) {
 		t, i = v.BoolNode(n)
 	case *ast.StringNode:
 		t, i = v.StringNode(n)
+	case *ast.TemplateNode:
+		t, i = v.TemplateNode(n)
 	case *ast.ConstantNode:
 		t, i = v.ConstantNode(n)
 	case *ast.UnaryNode:
@@ -110,6 +126,12 @@ func (v *CheckVisitor) visit(node ast.Node) (reflect.Type, Info) {
 		t, i = v.ClosureNode(n)
 	case *ast.PointerNode:
 		t, i = v.PointerNode(n)
+	case *ast.VariableDeclaratorNode:
+		t, i = v.VariableDeclaratorNode(n)
+	case *ast.SwitchNode:
+		t, i = v.SwitchNode(n)
+	case *ast.CaseNode:
+		t, i = v.CaseNode(n)
 	case *ast.ConditionalNode:
 		t, i = v.ConditionalNode(n)
 	case *ast.ArrayNode:
@@ -118,6 +140,8 @@ func (v *CheckVisitor) visit(node ast.Node) (reflect.Type, Info) {
 		t, i = v.MapNode(n)
 	case *ast.PairNode:
 		t, i = v.PairNode(n)
+	case *ast.SpreadNode:
+		t, i = v.SpreadNode(n)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
@@ -126,14 +150,22 @@ func (v *CheckVisitor) visit(node ast.Node) (reflect.Type, Info) {
 	return t, i
 }
 
-func (v *CheckVisitor) error(node ast.Node, format string, args ...interface{}) (reflect.Type, Info) {
-	if v.err == nil { // show first error
-		v.err = &file.Error{
-			Location: node.Location(),
-			Message:  fmt.Sprintf(format, args...),
+// error records the error and returns the type of the node with the error.
+// It is interface, which is accepted everywhere, so checking goes on without
+// more errors caused by this one.
+func (v *CheckVisitor) error(node ast.Node, code file.ErrorCode, format string, args ...interface{}) (reflect.Type, Info) {
+	err := &file.Error{
+		Location: node.Location(),
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	for _, e := range v.errors {
+		if e.Location == err.Location && e.Message == err.Message {
+			return AnyType, Info{} // Some nodes are visited twice.
 		}
 	}
-	return AnyType, Info{} // interface represent undefined type
+	v.errors = append(v.errors, err)
+	return AnyType, Info{}
 }
 
 func (v *CheckVisitor) NilNode(*ast.NilNode) (reflect.Type, Info) {
@@ -141,13 +173,18 @@ func (v *CheckVisitor) NilNode(*ast.NilNode) (reflect.Type, Info) {
 }
 
 func (v *CheckVisitor) IdentifierNode(node *ast.IdentifierNode) (reflect.Type, Info) {
+	for i := len(v.variables) - 1; i >= 0; i-- {
+		if v.variables[i].name == node.Value {
+			return v.variables[i].t, Info{}
+		}
+	}
 	if v.config.Types == nil {
 		node.Deref = true
 		return AnyType, Info{}
 	}
 	if t, ok := v.config.Types[node.Value]; ok {
 		if t.Ambiguous {
-			return v.error(node, "ambiguous identifier %v", node.Value)
+			return v.error(node, file.AmbiguousIdentifier, "ambiguous identifier %v", node.Value)
 		}
 		d, c := Deref(t.Type)
 		node.Deref = c
@@ -162,7 +199,7 @@ func (v *CheckVisitor) IdentifierNode(node *ast.IdentifierNode) (reflect.Type, I
 		}
 		return AnyType, Info{}
 	}
-	return v.error(node, "unknown name %v", node.Value)
+	return v.error(node, file.UnknownIdentifier, "unknown name %v", node.Value)
 }
 
 func (v *CheckVisitor) IntegerNode(*ast.IntegerNode) (reflect.Type, Info) {
@@ -170,6 +207,9 @@ func (v *CheckVisitor) IntegerNode(*ast.IntegerNode) (reflect.Type, Info) {
 }
 
 func (v *CheckVisitor) FloatNode(*ast.FloatNode) (reflect.Type, Info) {
+	if v.config.Decimal {
+		return DecimalType, Info{}
+	}
 	return FloatType, Info{}
 }
 
@@ -181,6 +221,13 @@ func (v *CheckVisitor) StringNode(*ast.StringNode) (reflect.Type, Info) {
 	return StringType, Info{}
 }
 
+func (v *CheckVisitor) TemplateNode(node *ast.TemplateNode) (reflect.Type, Info) {
+	for _, part := range node.Parts {
+		v.visit(part) // Any type can be interpolated.
+	}
+	return StringType, Info{}
+}
+
 func (v *CheckVisitor) ConstantNode(node *ast.ConstantNode) (reflect.Type, Info) {
 	return reflect.TypeOf(node.Value), Info{}
 }
@@ -207,10 +254,10 @@ func (v *CheckVisitor) UnaryNode(node *ast.UnaryNode) (reflect.Type, Info) {
 		}
 
 	default:
-		return v.error(node, "unknown operator (%v)", node.Operator)
+		return v.error(node, file.TypeMismatch, "unknown operator (%v)", node.Operator)
 	}
 
-	return v.error(node, `invalid operation: %v (mismatched type %v)`, node.Operator, t)
+	return v.error(node, file.TypeMismatch, `invalid operation: %v (mismatched type %v)`, node.Operator, t)
 }
 
 func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
@@ -225,6 +272,16 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 		}
 	}
 
+	if IsDuration(l) && !IsAny(r) || IsDuration(r) && !IsAny(l) {
+		switch node.Operator {
+		case "<", ">", ">=", "<=", "+", "-", "*", "/", "%", "div", "&", "|", "xor", "<<", ">>", "**", "^", "..":
+			if t, ok := durationOperation(node.Operator, l, r); ok {
+				return t, Info{}
+			}
+			return v.error(node, file.TypeMismatch, `invalid operation: %v (mismatched types %v and %v)`, node.Operator, l, r)
+		}
+	}
+
 	switch node.Operator {
 	case "==", "!=":
 		if IsNumber(l) && IsNumber(r) {
@@ -240,6 +297,15 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 			return BoolType, Info{}
 		}
 
+	case "??":
+		if l == nil {
+			return r, Info{}
+		}
+		if r == nil || l == r {
+			return l, Info{}
+		}
+		return AnyType, Info{}
+
 	case "or", "||", "and", "&&":
 		if IsBool(l) && IsBool(r) {
 			return BoolType, Info{}
@@ -273,7 +339,7 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 			return AnyType, Info{}
 		}
 
-	case "/", "*":
+	case "*":
 		if IsNumber(l) && IsNumber(r) {
 			return ToNumbertype(l, r), Info{}
 		}
@@ -281,11 +347,39 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 			return AnyType, Info{}
 		}
 
+	case "/":
+		if IsNumber(l) && IsNumber(r) {
+			if v.config.Decimal || IsDecimal(l) || IsDecimal(r) {
+				return DecimalType, Info{}
+			}
+			return FloatType, Info{}
+		}
+		if DualAnyOf(l, r, IsNumber) {
+			if v.config.Decimal {
+				return DecimalType, Info{}
+			}
+			return AnyType, Info{}
+		}
+
+	case "div", "&", "|", "xor", "<<", ">>":
+		if IsInteger(l) && IsInteger(r) {
+			return IntegerType, Info{}
+		}
+		if DualAnyOf(l, r, IsInteger) {
+			return IntegerType, Info{}
+		}
+
 	case "**", "^":
 		if IsNumber(l) && IsNumber(r) {
+			if IsDecimal(l) || IsDecimal(r) {
+				return DecimalType, Info{}
+			}
 			return FloatType, Info{}
 		}
 		if DualAnyOf(l, r, IsNumber) {
+			if IsDecimal(l) || IsDecimal(r) {
+				return DecimalType, Info{}
+			}
 			return FloatType, Info{}
 		}
 
@@ -293,6 +387,9 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 		if IsInteger(l) && IsInteger(r) {
 			return ToNumbertype(l, r), Info{}
 		}
+		if IsNumber(l) && IsNumber(r) && (IsDecimal(l) || IsDecimal(r)) {
+			return DecimalType, Info{}
+		}
 		if DualAnyOf(l, r, IsInteger) {
 			return AnyType, Info{}
 		}
@@ -335,7 +432,7 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 		if s, ok := node.Right.(*ast.StringNode); ok {
 			r, err := regexp.Compile(s.Value)
 			if err != nil {
-				return v.error(node, err.Error())
+				return v.error(node, file.SyntaxError, err.Error())
 			}
 			node.Regexp = r
 		}
@@ -364,11 +461,50 @@ func (v *CheckVisitor) BinaryNode(node *ast.BinaryNode) (reflect.Type, Info) {
 		}
 
 	default:
-		return v.error(node, "unknown operator (%v)", node.Operator)
+		return v.error(node, file.TypeMismatch, "unknown operator (%v)", node.Operator)
 
 	}
 
-	return v.error(node, `invalid operation: %v (mismatched types %v and %v)`, node.Operator, l, r)
+	return v.error(node, file.TypeMismatch, `invalid operation: %v (mismatched types %v and %v)`, node.Operator, l, r)
+}
+
+// durationOperation returns the type of the operation with a duration.
+// Durations are integers for reflect, but only comparison, addition and
+// subtraction of durations, scaling by numbers and the ratio of durations
+// are defined for them.
+func durationOperation(operator string, l, r reflect.Type) (reflect.Type, bool) {
+	scale := func(t reflect.Type) bool {
+		return !IsDuration(t) && IsNumber(t)
+	}
+	switch operator {
+	case "<", ">", ">=", "<=":
+		if IsDuration(l) && IsDuration(r) {
+			return BoolType, true
+		}
+	case "+":
+		if IsDuration(l) && IsDuration(r) {
+			return DurationType, true
+		}
+		if l == TimeType || r == TimeType {
+			return TimeType, true
+		}
+	case "-":
+		if IsDuration(l) && IsDuration(r) {
+			return DurationType, true
+		}
+	case "*":
+		if IsDuration(l) && scale(r) || scale(l) && IsDuration(r) {
+			return DurationType, true
+		}
+	case "/":
+		if IsDuration(l) && IsDuration(r) {
+			return FloatType, true
+		}
+		if IsDuration(l) && scale(r) {
+			return DurationType, true
+		}
+	}
+	return nil, false
 }
 
 func (v *CheckVisitor) ChainNode(node *ast.ChainNode) (reflect.Type, Info) {
@@ -381,7 +517,7 @@ func (v *CheckVisitor) MemberNode(node *ast.MemberNode) (reflect.Type, Info) {
 
 	if name, ok := node.Property.(*ast.StringNode); ok {
 		if base == nil {
-			return v.error(node, "type %v has no field %v", base, name.Value)
+			return v.error(node, file.UnknownIdentifier, "type %v has no field %v", base, name.Value)
 		}
 		// First, check methods defined on base type itself,
 		// independent of which type it is. Without dereferencing.
@@ -411,15 +547,35 @@ func (v *CheckVisitor) MemberNode(node *ast.MemberNode) (reflect.Type, Info) {
 
 	case reflect.Map:
 		if !prop.AssignableTo(base.Key()) {
-			return v.error(node.Property, "cannot use %v to get an element from %v", prop, base)
+			return v.error(node.Property, file.TypeMismatch, "cannot use %v to get an element from %v", prop, base)
 		}
 		t, c := Deref(base.Elem())
 		node.Deref = c
 		return t, Info{}
 
-	case reflect.Array, reflect.Slice:
+	case reflect.Array, reflect.Slice, reflect.String:
 		if !IsInteger(prop) && !IsAny(prop) {
-			return v.error(node.Property, "array elements can only be selected using an integer (got %v)", prop)
+			return v.error(node.Property, file.TypeMismatch, "array elements can only be selected using an integer (got %v)", prop)
+		}
+		if index, ok := constantInt(node.Property); ok {
+			if length, ok := constantLen(node.Node, base); ok {
+				if index < -length || index >= length {
+					what := "array"
+					if base.Kind() == reflect.String {
+						what = "string"
+					}
+					return v.error(node.Property, file.IndexOutOfRange, "index out of range: %v (%s length is %v)", index, what, length)
+				}
+				if index < 0 {
+					property := &ast.IntegerNode{Value: index + length}
+					property.SetLocation(node.Property.Location())
+					property.SetType(IntegerType)
+					node.Property = property
+				}
+			}
+		}
+		if base.Kind() == reflect.String {
+			return StringType, Info{}
 		}
 		t, c := Deref(base.Elem())
 		node.Deref = c
@@ -437,14 +593,14 @@ func (v *CheckVisitor) MemberNode(node *ast.MemberNode) (reflect.Type, Info) {
 			}
 			if len(v.parents) > 1 {
 				if _, ok := v.parents[len(v.parents)-2].(*ast.CallNode); ok {
-					return v.error(node, "type %v has no method %v", base, propertyName)
+					return v.error(node, file.UnknownIdentifier, "type %v has no method %v", base, propertyName)
 				}
 			}
-			return v.error(node, "type %v has no field %v", base, propertyName)
+			return v.error(node, file.UnknownIdentifier, "type %v has no field %v", base, propertyName)
 		}
 	}
 
-	return v.error(node, "type %v[%v] is undefined", base, prop)
+	return v.error(node, file.UnknownIdentifier, "type %v[%v] is undefined", base, prop)
 }
 
 func (v *CheckVisitor) SliceNode(node *ast.SliceNode) (reflect.Type, Info) {
@@ -456,24 +612,71 @@ func (v *CheckVisitor) SliceNode(node *ast.SliceNode) (reflect.Type, Info) {
 	case reflect.String, reflect.Array, reflect.Slice:
 		// ok
 	default:
-		return v.error(node, "cannot slice %v", t)
+		return v.error(node, file.TypeMismatch, "cannot slice %v", t)
 	}
 
 	if node.From != nil {
 		from, _ := v.visit(node.From)
 		if !IsInteger(from) && !IsAny(from) {
-			return v.error(node.From, "non-integer slice index %v", from)
+			return v.error(node.From, file.TypeMismatch, "non-integer slice index %v", from)
 		}
 	}
 	if node.To != nil {
 		to, _ := v.visit(node.To)
 		if !IsInteger(to) && !IsAny(to) {
-			return v.error(node.To, "non-integer slice index %v", to)
+			return v.error(node.To, file.TypeMismatch, "non-integer slice index %v", to)
+		}
+	}
+	if node.Step != nil {
+		step, _ := v.visit(node.Step)
+		if !IsInteger(step) && !IsAny(step) {
+			return v.error(node.Step, file.TypeMismatch, "non-integer slice step %v", step)
+		}
+		if s, ok := constantInt(node.Step); ok && s == 0 {
+			return v.error(node.Step, file.InvalidArgument, "slice step cannot be zero")
+		}
+		if t.Kind() == reflect.Array {
+			// Stepped slice is a copy of the elements.
+			return reflect.SliceOf(t.Elem()), Info{}
 		}
 	}
 	return t, Info{}
 }
 
+// constantInt returns the value of an integer literal, including negative
+// ones, which are parsed as unary minus.
+func constantInt(node ast.Node) (int, bool) {
+	switch n := node.(type) {
+	case *ast.IntegerNode:
+		return n.Value, true
+	case *ast.UnaryNode:
+		if i, ok := n.Node.(*ast.IntegerNode); ok && n.Operator == "-" {
+			return -i.Value, true
+		}
+	}
+	return 0, false
+}
+
+// constantLen returns the length of the array or string (in runes), if it
+// is known at compile time.
+func constantLen(node ast.Node, t reflect.Type) (int, bool) {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return utf8.RuneCountInString(n.Value), true
+	case *ast.ArrayNode:
+		for _, node := range n.Nodes {
+			if _, ok := node.(*ast.SpreadNode); ok {
+				return 0, false
+			}
+		}
+		return len(n.Nodes), true
+	}
+	if t.Kind() == reflect.Array {
+		return t.Len(), true
+	}
+	return 0, false
+}
+
 func (v *CheckVisitor) CallNode(node *ast.CallNode) (reflect.Type, Info) {
 	fn, fnInfo := v.visit(node.Callee)
 
@@ -512,7 +715,7 @@ func (v *CheckVisitor) CallNode(node *ast.CallNode) (reflect.Type, Info) {
 
 		return v.checkFunc(fn, fnInfo.Method, node, fnName, node.Arguments)
 	}
-	return v.error(node, "%v is not callable", fn)
+	return v.error(node, file.NotCallable, "%v is not callable", fn)
 }
 
 // checkFunc checks func arguments and returns "return type" of func or method.
@@ -522,10 +725,10 @@ func (v *CheckVisitor) checkFunc(fn reflect.Type, method bool, node *ast.CallNod
 	}
 
 	if fn.NumOut() == 0 {
-		return v.error(node, "func %v doesn't return value", name)
+		return v.error(node, file.NotCallable, "func %v doesn't return value", name)
 	}
 	if numOut := fn.NumOut(); numOut > 2 {
-		return v.error(node, "func %v returns more then two values", name)
+		return v.error(node, file.NotCallable, "func %v returns more then two values", name)
 	}
 
 	numIn := fn.NumIn()
@@ -536,16 +739,32 @@ func (v *CheckVisitor) checkFunc(fn reflect.Type, method bool, node *ast.CallNod
 		numIn--
 	}
 
-	if fn.IsVariadic() {
+	var spread bool
+	if len(arguments) > 0 {
+		_, spread = arguments[len(arguments)-1].(*ast.SpreadNode)
+	}
+
+	if spread {
+		if !fn.IsVariadic() {
+			return v.error(arguments[len(arguments)-1], file.TypeMismatch, "cannot use ... in call to non-variadic %v", name)
+		}
+		// Spread argument replaces all variadic arguments, as in fn(a, xs...).
+		if len(arguments) > numIn {
+			return v.error(node, file.ArgumentCount, "too many arguments to call %v", name)
+		}
+		if len(arguments) < numIn {
+			return v.error(node, file.ArgumentCount, "not enough arguments to call %v", name)
+		}
+	} else if fn.IsVariadic() {
 		if len(arguments) < numIn-1 {
-			return v.error(node, "not enough arguments to call %v", name)
+			return v.error(node, file.ArgumentCount, "not enough arguments to call %v", name)
 		}
 	} else {
 		if len(arguments) > numIn {
-			return v.error(node, "too many arguments to call %v", name)
+			return v.error(node, file.ArgumentCount, "too many arguments to call %v", name)
 		}
 		if len(arguments) < numIn {
-			return v.error(node, "not enough arguments to call %v", name)
+			return v.error(node, file.ArgumentCount, "not enough arguments to call %v", name)
 		}
 	}
 
@@ -556,9 +775,31 @@ func (v *CheckVisitor) checkFunc(fn reflect.Type, method bool, node *ast.CallNod
 		offset = 1
 	}
 
+	// All arguments are checked, so that every mismatch is reported.
+	mismatch := false
 	for i, arg := range arguments {
 		t, _ := v.visit(arg)
 
+		if _, ok := arg.(*ast.SpreadNode); ok {
+			if IsAny(t) {
+				continue
+			}
+			if !IsArray(t) {
+				v.error(arg, file.TypeMismatch, "cannot use %v as variadic argument to call %v", t, name)
+				mismatch = true
+				continue
+			}
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			in := fn.In(fn.NumIn() - 1).Elem()
+			if !t.Elem().AssignableTo(in) && t.Elem().Kind() != reflect.Interface {
+				v.error(arg, file.TypeMismatch, "cannot use %v as variadic argument (type []%v) to call %v", t, in, name)
+				mismatch = true
+			}
+			continue
+		}
+
 		var in reflect.Type
 		if fn.IsVariadic() && i >= numIn-1 {
 			// For variadic arguments fn(xs ...int), go replaces type of xs (int) with ([]int).
@@ -578,9 +819,13 @@ func (v *CheckVisitor) checkFunc(fn reflect.Type, method bool, node *ast.CallNod
 		}
 
 		if !t.AssignableTo(in) && t.Kind() != reflect.Interface {
-			return v.error(arg, "cannot use %v as argument (type %v) to call %v ", t, in, name)
+			v.error(arg, file.TypeMismatch, "cannot use %v as argument (type %v) to call %v ", t, in, name)
+			mismatch = true
 		}
 	}
+	if mismatch {
+		return AnyType, Info{}
+	}
 
 	if !fn.IsVariadic() {
 	funcTypes:
@@ -616,23 +861,42 @@ func (v *CheckVisitor) checkFunc(fn reflect.Type, method bool, node *ast.CallNod
 }
 
 func (v *CheckVisitor) BuiltinNode(node *ast.BuiltinNode) (reflect.Type, Info) {
-	space, ok := namespaces.Get(node.Namespace)
+	space, ok := v.namespaces.Get(node.Namespace)
 
 	if !ok {
-		return v.error(node, "there is no builtin namespace %s", node.Namespace)
+		return v.error(node, file.UnknownIdentifier, "there is no builtin namespace %s", node.Namespace)
 	}
 
 	return space.Check(v.ex, node)
 }
 
 func (v *CheckVisitor) ClosureNode(node *ast.ClosureNode) (reflect.Type, Info) {
+	size := len(v.variables)
+	for i, name := range node.Params {
+		t := IntegerType // index
+		if i == 0 {
+			t = v.element()
+		}
+		v.variables = append(v.variables, variable{name: name, t: t})
+	}
 	t, _ := v.visit(node.Node)
+	v.variables = v.variables[:size]
 	return reflect.FuncOf([]reflect.Type{AnyType}, []reflect.Type{t}, false), Info{}
 }
 
 func (v *CheckVisitor) PointerNode(node *ast.PointerNode) (reflect.Type, Info) {
 	if len(v.collections) == 0 {
-		return v.error(node, "cannot use pointer accessor outside closure")
+		return v.error(node, file.SyntaxError, "cannot use pointer accessor outside closure")
+	}
+	if node.Name == "acc" {
+		// #acc is the accumulator of the nearest reduce, also in closures
+		// nested into its closure.
+		for i := len(v.accumulators) - 1; i >= 0; i-- {
+			if t := v.accumulators[i]; t != nil {
+				return t, Info{}
+			}
+		}
+		return v.error(node, file.SyntaxError, "cannot use #acc outside reduce")
 	}
 
 	collection := v.collections[len(v.collections)-1]
@@ -642,13 +906,108 @@ func (v *CheckVisitor) PointerNode(node *ast.PointerNode) (reflect.Type, Info) {
 	case reflect.Array, reflect.Slice:
 		return collection.Elem(), Info{}
 	}
-	return v.error(node, "cannot use %v as array", collection)
+	return v.error(node, file.TypeMismatch, "cannot use %v as array", collection)
+}
+
+func (v *CheckVisitor) VariableDeclaratorNode(node *ast.VariableDeclaratorNode) (reflect.Type, Info) {
+	t, _ := v.visit(node.Value)
+	v.variables = append(v.variables, variable{name: node.Name, t: t})
+	t, i := v.visit(node.Expr)
+	v.variables = v.variables[:len(v.variables)-1]
+	return t, i
+}
+
+// element returns the type of elements of the collection the closure is
+// called for.
+func (v *CheckVisitor) element() reflect.Type {
+	if len(v.collections) == 0 {
+		return AnyType
+	}
+	collection := v.collections[len(v.collections)-1]
+	switch collection.Kind() {
+	case reflect.Array, reflect.Slice:
+		return collection.Elem()
+	}
+	return AnyType
+}
+
+func (v *CheckVisitor) SwitchNode(node *ast.SwitchNode) (reflect.Type, Info) {
+	t, _ := v.visit(node.Node)
+
+	v.variables = append(v.variables, variable{name: ast.SwitchSubject, t: t})
+	arms := make([]ast.Node, 0, len(node.Cases)+1)
+	for _, c := range node.Cases {
+		v.visit(c)
+		arms = append(arms, c)
+	}
+	v.variables = v.variables[:len(v.variables)-1]
+
+	if node.Default != nil {
+		v.visit(node.Default)
+		arms = append(arms, node.Default)
+	} else if v.config.Expect != reflect.Invalid && len(v.parents) == 1 {
+		return v.error(node, file.TypeMismatch, "switch must have a default case, as the result is expected to be %v", v.config.Expect)
+	} else if len(v.parents) > 1 && isOperand(v.parents[len(v.parents)-2]) {
+		return v.error(node, file.TypeMismatch, "switch must have a default case to be used as an operand or argument")
+	}
+
+	var result reflect.Type
+	for _, arm := range arms {
+		t := arm.Type()
+		if t == nil || t == NilType {
+			continue
+		}
+		switch {
+		case result == nil || t.AssignableTo(result):
+			if result == nil {
+				result = t
+			}
+		case result.AssignableTo(t):
+			result = t
+		case IsNumber(result) && IsNumber(t):
+			result = AnyType
+		default:
+			return v.error(arm, file.TypeMismatch, "switch arms have incompatible types %v and %v", result, t)
+		}
+	}
+
+	if result == nil {
+		return NilType, Info{}
+	}
+	if node.Default == nil {
+		// Without the default case the result may be nil.
+		return AnyType, Info{}
+	}
+	return result, Info{}
+}
+
+// isOperand reports whether the parent uses the value of its child as an
+// operand or argument. The ?? operator is the exception, as it replaces the
+// nil of a switch without the default case.
+func isOperand(parent ast.Node) bool {
+	switch n := parent.(type) {
+	case *ast.BinaryNode:
+		return n.Operator != "??"
+	case *ast.UnaryNode, *ast.CallNode, *ast.BuiltinNode, *ast.MemberNode, *ast.SliceNode:
+		return true
+	}
+	return false
+}
+
+func (v *CheckVisitor) CaseNode(node *ast.CaseNode) (reflect.Type, Info) {
+	for _, cond := range node.Conditions {
+		c, _ := v.visit(cond)
+		if !IsBool(c) && !IsAny(c) {
+			return v.error(cond, file.TypeMismatch, "non-bool expression (type %v) used as case condition", c)
+		}
+	}
+	return v.visit(node.Expr)
 }
 
 func (v *CheckVisitor) ConditionalNode(node *ast.ConditionalNode) (reflect.Type, Info) {
 	c, _ := v.visit(node.Cond)
 	if !IsBool(c) && !IsAny(c) {
-		return v.error(node.Cond, "non-bool expression (type %v) used as condition", c)
+		return v.error(node.Cond, file.TypeMismatch, "non-bool expression (type %v) used as condition", c)
 	}
 
 	t1, _ := v.visit(node.Exp1)
@@ -671,20 +1030,42 @@ func (v *CheckVisitor) ConditionalNode(node *ast.ConditionalNode) (reflect.Type,
 
 func (v *CheckVisitor) ArrayNode(node *ast.ArrayNode) (reflect.Type, Info) {
 	for _, node := range node.Nodes {
-		v.visit(node)
+		t, _ := v.visit(node)
+		if _, ok := node.(*ast.SpreadNode); ok && !IsArray(t) && !IsAny(t) {
+			return v.error(node, file.TypeMismatch, "cannot spread %v in array", t)
+		}
 	}
 	return ArrayType, Info{}
 }
 
 func (v *CheckVisitor) MapNode(node *ast.MapNode) (reflect.Type, Info) {
 	for _, pair := range node.Pairs {
-		v.visit(pair)
+		t, _ := v.visit(pair)
+		if _, ok := pair.(*ast.SpreadNode); ok && !IsAny(t) {
+			if !IsMap(t) {
+				return v.error(pair, file.TypeMismatch, "cannot spread %v in map", t)
+			}
+			m := t
+			for m.Kind() == reflect.Ptr {
+				m = m.Elem()
+			}
+			if m.Key().Kind() != reflect.String && !IsAny(m.Key()) {
+				return v.error(pair, file.TypeMismatch, "cannot spread %v in map (keys must be strings)", t)
+			}
+		}
 	}
 	return MapType, Info{}
 }
 
 func (v *CheckVisitor) PairNode(node *ast.PairNode) (reflect.Type, Info) {
-	v.visit(node.Key)
+	k, _ := v.visit(node.Key)
+	if !IsString(k) && !IsAny(k) {
+		return v.error(node.Key, file.TypeMismatch, "map key must be a string (got %v)", k)
+	}
 	v.visit(node.Value)
 	return NilType, Info{}
 }
+
+func (v *CheckVisitor) SpreadNode(node *ast.SpreadNode) (reflect.Type, Info) {
+	return v.visit(node.Node)
+}
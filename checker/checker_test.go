@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/antonmedv/expr"
@@ -122,6 +123,68 @@ var successTests = []string{
 	"Any + Duration == Time",
 	"Any.A?.B == nil",
 	"math.abs(-1) == 1",
+	"math.floor(Float) + math.floor(Int) + 0.5 == 1.5",
+	"math.max(Int, Int) + 1 == 1",
+	"math.max(Int, Float) + 0.5 == 1.5",
+	"math.sqrt(Int) == 1.0",
+	"math.round(Any, Int) == Any",
+	"math.sign(Float) + 1 == 1",
+	"math.pi * 2 > 6.0",
+	"math.maxInt > 0",
+	"strings.upper(String) + strings.trim(String, Any) == String",
+	"strings.length(String) + strings.indexOf(String, String) > 0",
+	"strings.split(String, String)[0] == String",
+	"strings.join(strings.split(String, String), String) == String",
+	"strings.join(ArrayOfAny) == String",
+	"strings.padLeft(Any, Int) == String",
+	"time.now() - Time > time.duration(String)",
+	"time.addDate(Time, Int, Int, Int) + Duration == Time",
+	"time.weekday(time.date(Int, Int, Int)) + time.unix(Time) > 0",
+	"time.format(time.inLocation(Any, String), String) == String",
+	"reduce(ArrayOfInt, {#acc + #}, 0) != nil",
+	"sum(ArrayOfInt) + avg(ArrayOfFoo, {len(.Bar.Baz)}) > 0.0",
+	"min(ArrayOfInt) + max(ArrayOfFoo, {len(.Bar.Baz)}) > 0",
+	"sortBy(ArrayOfFoo, {.Bar.Baz})[0].Bar.Baz == String",
+	"groupBy(ArrayOfFoo, {.Bar.Baz})[String][0] != nil",
+	"uniq(ArrayOfInt)[0] + first(ArrayOfInt) + last(ArrayOfInt) > 0",
+	"find(ArrayOfFoo, {.Bar.Baz == String}).Bar.Baz == String",
+	"findIndex(ArrayOfInt, {# > 1}) + len(flatten(ArrayOfAny)) > 0",
+	"take(ArrayOfInt, Int)[0] + drop(ArrayOfInt, 1)[0] > 0",
+	"zip(ArrayOfInt, ArrayOfFoo)[0][1] != nil",
+	"all(MapOfFoo, {.Key == String && .Value.Bar.Baz == String})",
+	"filter(MapOfFoo, {len(.Key) > 0})[0].Value.Bar.Baz == String",
+	"map(MapOfFoo, {.Value})[0].Bar.Baz == String",
+	"count(MapOfAny, {.Value}) + len(keys(MapOfFoo)[0]) > 0",
+	"values(MapOfFoo)[0].Bar.Baz == entries(MapOfFoo)[0].Value.Bar.Baz",
+	"all(ArrayOfFoo, foo => any(ArrayOfFoo, x => x.Bar.Baz == foo.Bar.Baz))",
+	"map(ArrayOfFoo, (foo, i) => foo.Bar.Baz + String)[Int] == String",
+	"filter(MapOfFoo, e => e.Key == e.Value.Bar.Baz)[0].Value.Bar.Baz == String",
+	"map(ArrayOfInt, Int => Int + 1)[0] + Int > 0",
+	"let foo = ArrayOfFoo[0]; foo.Bar.Baz == String",
+	"(Foo?.Bar?.Baz ?? String) + String == String",
+	"(IntPtr ?? Int) + Int > 0",
+	"(nil ?? Int) + Int > 0",
+	"let xs = filter(ArrayOfInt, {# > Int}); len(xs) > 0 && sum(xs) > xs[0]",
+	"let Int = String; Int + String == String",
+	"`${Int} ${Foo} ${ArrayOfFoo} ${Any}` + String == String",
+	"switch Int { case 1, 2 => true; case 3..5 => Int > 0; default => false }",
+	`switch String { case matches "^a" => String; case "b" => "c"; default => "d" } == String`,
+	"(switch Int { case 1 => 1; case 2 => 2.5; default => Int }) > 0",
+	"switch Any { case 1 => nil; default => true }",
+	"((switch Int { case 1 => 1 }) ?? 0) > 0",
+	"switch ArrayOfFoo[0] { case Foo => Foo.Bar.Baz == String; default => false }",
+	"(Int & 0xFF | Int << 2 xor Int >> 1) div 3 + Int == Int",
+	"(Any & Int) + (Int div Any) == Int",
+	"Int / Int == 0.5",
+	"len([...ArrayOfInt, ...ArrayOfFoo, ...Any, Int]) > 0",
+	"len({...MapOfFoo, ...MapOfAny, ...Any, Int: Int}) > 0",
+	"ArrayOfInt[-1] + ArrayOfInt[::2][0] + len(ArrayOfFoo[Int::-1]) == Int",
+	"String[-1] + String[::Int] == String",
+	"int(String) + int(Float) + int(Bool) + int(Any) + int(Duration) == Int",
+	"float(Int) + float(String) > Float",
+	"string(Foo) + string(ArrayOfInt) + type(Any) == String",
+	"bool(Int) || bool(String) || bool(Any)",
+	"Variadic(Int, ArrayOfInt...) && Variadic(Int, ArrayOfAny...) && Variadic(Int, Any...) && Fast(ArrayOfFoo...) == nil",
 }
 
 func TestCheck(t *testing.T) {
@@ -368,6 +431,9 @@ NilFn() and BoolFn()
 func NilFn doesn't return value (1:1)
  | NilFn() and BoolFn()
  | ^
+unknown name BoolFn (1:13)
+ | NilFn() and BoolFn()
+ | ............^
 
 'str' in String
 invalid operation: in (mismatched types string and string) (1:7)
@@ -404,8 +470,16 @@ cannot use string as argument (type int) to call Variadic  (1:13)
  | Variadic(0, '')
  | ............^
 
+Variadic(0, '', 1, '')
+cannot use string as argument (type int) to call Variadic  (1:13)
+ | Variadic(0, '', 1, '')
+ | ............^
+cannot use string as argument (type int) to call Variadic  (1:20)
+ | Variadic(0, '', 1, '')
+ | ...................^
+
 count(1, {#})
-builtin count takes only array (got int) (1:7)
+builtin count takes only array or map (got int) (1:7)
  | count(1, {#})
  | ......^
 
@@ -425,7 +499,7 @@ closure should return boolean (got string) (1:20)
  | ...................^
 
 map(1, {2})
-builtin map takes only array (got int) (1:5)
+builtin map takes only array or map (got int) (1:5)
  | map(1, {2})
  | ....^
 
@@ -465,12 +539,12 @@ invalid argument for len (type int) (1:1)
  | ^
 
 any(42, {#})
-builtin any takes only array (got int) (1:5)
+builtin any takes only array or map (got int) (1:5)
  | any(42, {#})
  | ....^
 
 filter(42, {#})
-builtin filter takes only array (got int) (1:8)
+builtin filter takes only array or map (got int) (1:8)
  | filter(42, {#})
  | .......^
 
@@ -478,6 +552,241 @@ MapOfAny[0]
 cannot use int to get an element from map[string]interface {} (1:10)
  | MapOfAny[0]
  | .........^
+
+math.floor("1")
+math.floor expects a number as input (got string) (1:1)
+ | math.floor("1")
+ | ^
+
+math.round(Float, Float)
+math.round expects an integer as digits (got float64) (1:19)
+ | math.round(Float, Float)
+ | ..................^
+
+math.max(Int, String)
+math.max expects numbers as input (got [int string]) (1:1)
+ | math.max(Int, String)
+ | ^
+
+strings.upper(Int)
+strings.upper expects string as argument 1 (got int) (1:15)
+ | strings.upper(Int)
+ | ..............^
+
+strings.repeat(String, Float)
+strings.repeat expects integer as argument 2 (got float64) (1:24)
+ | strings.repeat(String, Float)
+ | .......................^
+
+strings.join(ArrayOfFoo)
+strings.join expects array of strings as argument 1 (got []mock.Foo) (1:14)
+ | strings.join(ArrayOfFoo)
+ | .............^
+
+time.format(String, String)
+time.format expects time as argument 1 (got string) (1:13)
+ | time.format(String, String)
+ | ............^
+
+time.truncate(Time, Int)
+time.truncate expects duration as argument 2 (got int) (1:21)
+ | time.truncate(Time, Int)
+ | ....................^
+
+sum(ArrayOfFoo)
+builtin sum takes only numbers (got mock.Foo) (1:1)
+ | sum(ArrayOfFoo)
+ | ^
+
+sortBy(ArrayOfFoo, {.Bar})
+closure should return number, string or time (got mock.Bar) (1:20)
+ | sortBy(ArrayOfFoo, {.Bar})
+ | ...................^
+
+take(ArrayOfInt, String)
+builtin take takes only integer as count (got string) (1:18)
+ | take(ArrayOfInt, String)
+ | .................^
+
+find(ArrayOfInt, {#})
+closure should return boolean (got int) (1:18)
+ | find(ArrayOfInt, {#})
+ | .................^
+
+first(Int)
+builtin first takes only array (got int) (1:7)
+ | first(Int)
+ | ......^
+
+map(MapOfFoo, {.Value + 1})
+invalid operation: + (mismatched types mock.Foo and int) (1:23)
+ | map(MapOfFoo, {.Value + 1})
+ | ......................^
+
+keys(ArrayOfInt)
+builtin keys takes only map (got []int) (1:6)
+ | keys(ArrayOfInt)
+ | .....^
+
+map(ArrayOfFoo, (foo, i) => foo.Bar.Baz + i)
+invalid operation: + (mismatched types string and int) (1:41)
+ | map(ArrayOfFoo, (foo, i) => foo.Bar.Baz + i)
+ | ........................................^
+
+(String ?? "a") + Int
+invalid operation: + (mismatched types string and int) (1:17)
+ | (String ?? "a") + Int
+ | ................^
+
+let s = String; s + Int
+invalid operation: + (mismatched types string and int) (1:19)
+ | let s = String; s + Int
+ | ..................^
+
+map(ArrayOfFoo, foo => foo.Baz)
+type mock.Foo has no field Baz (1:28)
+ | map(ArrayOfFoo, foo => foo.Baz)
+ | ...........................^
+
+Int & Float
+invalid operation: & (mismatched types int and float64) (1:5)
+ | Int & Float
+ | ....^
+
+String div 2
+invalid operation: div (mismatched types string and int) (1:8)
+ | String div 2
+ | .......^
+
+switch Int { case 1 => "a"; default => 2 }
+switch arms have incompatible types string and int (1:40)
+ | switch Int { case 1 => "a"; default => 2 }
+ | .......................................^
+
+switch Int { case "a" => 1; default => 2 }
+invalid operation: == (mismatched types int and string) (1:19)
+ | switch Int { case "a" => 1; default => 2 }
+ | ..................^
+
+switch String { case matches "(" => 1 }
+error parsing regexp: missing closing ): ` + "`(`" + ` (1:22)
+ | switch String { case matches "(" => 1 }
+ | .....................^
+
+(switch Int { case 1 => 1 }) + 1
+switch must have a default case to be used as an operand or argument (1:2)
+ | (switch Int { case 1 => 1 }) + 1
+ | .^
+
+len(switch Int { case 1 => "a" })
+switch must have a default case to be used as an operand or argument (1:5)
+ | len(switch Int { case 1 => "a" })
+ | ....^
+
+{(Int): 1}
+map key must be a string (got int) (1:3)
+ | {(Int): 1}
+ | ..^
+
+[...Int]
+cannot spread int in array (1:2)
+ | [...Int]
+ | .^
+
+{...ArrayOfInt}
+cannot spread []int in map (1:2)
+ | {...ArrayOfInt}
+ | .^
+
+FuncParamAny(ArrayOfAny...)
+cannot use ... in call to non-variadic FuncParamAny (1:24)
+ | FuncParamAny(ArrayOfAny...)
+ | .......................^
+
+Variadic(ArrayOfInt...)
+not enough arguments to call Variadic (1:1)
+ | Variadic(ArrayOfInt...)
+ | ^
+
+Variadic(Int, Int, ArrayOfInt...)
+too many arguments to call Variadic (1:1)
+ | Variadic(Int, Int, ArrayOfInt...)
+ | ^
+
+Variadic(Int, ArrayOfFoo...)
+cannot use []mock.Foo as variadic argument (type []int) to call Variadic (1:25)
+ | Variadic(Int, ArrayOfFoo...)
+ | ........................^
+
+Variadic(Int, Foo...)
+cannot use mock.Foo as variadic argument to call Variadic (1:18)
+ | Variadic(Int, Foo...)
+ | .................^
+
+[1, 2][2]
+index out of range: 2 (array length is 2) (1:8)
+ | [1, 2][2]
+ | .......^
+
+ArrayOfInt[::0]
+slice step cannot be zero (1:14)
+ | ArrayOfInt[::0]
+ | .............^
+
+ArrayOfInt[::String]
+non-integer slice step string (1:14)
+ | ArrayOfInt[::String]
+ | .............^
+
+int(ArrayOfInt)
+cannot convert []int to int (1:5)
+ | int(ArrayOfInt)
+ | ....^
+
+float(MapOfAny)
+cannot convert map[string]interface {} to float (1:7)
+ | float(MapOfAny)
+ | ......^
+
+bool(Time)
+cannot convert time.Time to bool (1:6)
+ | bool(Time)
+ | .....^
+
+float(nil)
+cannot convert nil to float (1:7)
+ | float(nil)
+ | ......^
+
+reduce(ArrayOfInt, {#acc.Foo})
+type int[string] is undefined (1:26)
+ | reduce(ArrayOfInt, {#acc.Foo})
+ | .........................^
+
+reduce(ArrayOfInt, {#acc + #}, "")
+invalid operation: + (mismatched types string and int) (1:26)
+ | reduce(ArrayOfInt, {#acc + #}, "")
+ | .........................^
+
+filter(ArrayOfInt, {#acc == nil})
+cannot use #acc outside reduce (1:21)
+ | filter(ArrayOfInt, {#acc == nil})
+ | ....................^
+
+1h + 1
+invalid operation: + (mismatched types time.Duration and int) (1:4)
+ | 1h + 1
+ | ...^
+
+2 / 1h
+invalid operation: / (mismatched types int and time.Duration) (1:3)
+ | 2 / 1h
+ | ..^
+
+1h % 2
+invalid operation: % (mismatched types time.Duration and int) (1:4)
+ | 1h % 2
+ | ...^
 `
 
 func TestCheck_error(t *testing.T) {
@@ -549,6 +858,18 @@ func TestCheck_AsBool(t *testing.T) {
 	assert.Equal(t, "expected bool, but got int", err.Error())
 }
 
+func TestCheck_switch_without_default(t *testing.T) {
+	tree, err := parser.Parse(`switch Int { case 1 => true }`)
+	require.NoError(t, err)
+
+	config := conf.New(mock.Env{})
+	expr.AsBool()(config)
+
+	_, err = checker.Check(tree, config)
+	assert.Error(t, err)
+	assert.Equal(t, "switch must have a default case, as the result is expected to be bool (1:1)\n | switch Int { case 1 => true }\n | ^", err.Error())
+}
+
 func TestCheck_AsInt64(t *testing.T) {
 	tree, err := parser.Parse(`true`)
 	require.NoError(t, err)
@@ -704,3 +1025,50 @@ func TestCheck_TypeWeights(t *testing.T) {
 		}
 	}
 }
+
+func TestCheck_Namespace(t *testing.T) {
+	config := conf.New(mock.Env{})
+	expr.Namespace(mock.NewGeo())(config)
+
+	tree, err := parser.ParseWithConfig(`geo.distance(Int, Float)`, config)
+	require.NoError(t, err)
+
+	typ, err := checker.Check(tree, config)
+	require.NoError(t, err)
+	assert.Equal(t, reflect.Float64, typ.Kind())
+
+	tree, err = parser.ParseWithConfig(`geo.distance(Int, String)`, config)
+	require.NoError(t, err)
+
+	_, err = checker.Check(tree, config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "geo.distance expects numbers (got string)")
+}
+
+func TestCheck_Namespace_not_shared(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(withGeo bool) {
+			defer wg.Done()
+
+			config := conf.New(mock.Env{})
+			if withGeo {
+				expr.Namespace(mock.NewGeo())(config)
+			}
+
+			tree, err := parser.ParseWithConfig(`geo.distance(1, 2)`, config)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = checker.Check(tree, config)
+			if withGeo {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "unknown name geo")
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
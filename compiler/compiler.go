@@ -6,9 +6,13 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
 	"github.com/antonmedv/expr/conf"
+	"github.com/antonmedv/expr/decimal"
 	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/namespaces"
 	"github.com/antonmedv/expr/parser"
+	"github.com/antonmedv/expr/util/typing"
 	. "github.com/antonmedv/expr/vm"
 	"github.com/antonmedv/expr/vm/runtime"
 )
@@ -32,6 +36,10 @@ func Compile(tree *parser.Tree, config *conf.Config) (program *Program, err erro
 	if config != nil {
 		c.mapEnv = config.MapEnv
 		c.cast = config.Expect
+		c.decimal = config.Decimal
+		c.namespaces = namespaces.With(config.Namespaces)
+	} else {
+		c.namespaces = namespaces.With(nil)
 	}
 
 	c.compile(tree.Node)
@@ -52,23 +60,47 @@ func Compile(tree *parser.Tree, config *conf.Config) (program *Program, err erro
 		Constants: c.constants,
 		Bytecode:  c.bytecode,
 		Arguments: c.arguments,
+		Variables: c.locals,
 	}
 	return
 }
 
 type compiler struct {
-	locations []file.Location
-	constants []interface{}
-	bytecode  []Opcode
-	index     map[interface{}]int
-	mapEnv    bool
-	cast      reflect.Kind
-	nodes     []ast.Node
-	chains    [][]int
-	arguments []int
+	locations  []file.Location
+	constants  []interface{}
+	bytecode   []Opcode
+	index      map[interface{}]int
+	mapEnv     bool
+	cast       reflect.Kind
+	decimal    bool // float literals and division are decimals
+	nodes      []ast.Node
+	chains     [][]int
+	arguments  []int
+	namespaces namespaces.Table
+	scopes     int   // number of open OpBegin scopes at the current point of bytecode
+	reducers   []int // scopes of the reducers, which #acc refers to
+	variables  []variable
+	locals     int
+}
+
+// variable is a named value visible at the current point of bytecode: a param
+// of the closure, looked up in the scope of the loop the closure is called in,
+// or a let binding, stored in a local slot.
+type variable struct {
+	name  string
+	scope int
+	index bool
+	local bool
+	slot  int
 }
 
 func (c *compiler) emitLocation(loc file.Location, op Opcode, arg int) int {
+	switch op {
+	case OpBegin:
+		c.scopes++
+	case OpEnd:
+		c.scopes--
+	}
 	c.bytecode = append(c.bytecode, op)
 	current := len(c.bytecode)
 	c.arguments = append(c.arguments, arg)
@@ -157,6 +189,8 @@ func (c *compiler) compile(node ast.Node) {
 		c.BoolNode(n)
 	case *ast.StringNode:
 		c.StringNode(n)
+	case *ast.TemplateNode:
+		c.TemplateNode(n)
 	case *ast.ConstantNode:
 		c.ConstantNode(n)
 	case *ast.UnaryNode:
@@ -177,6 +211,10 @@ func (c *compiler) compile(node ast.Node) {
 		c.ClosureNode(n)
 	case *ast.PointerNode:
 		c.PointerNode(n)
+	case *ast.VariableDeclaratorNode:
+		c.VariableDeclaratorNode(n)
+	case *ast.SwitchNode:
+		c.SwitchNode(n)
 	case *ast.ConditionalNode:
 		c.ConditionalNode(n)
 	case *ast.ArrayNode:
@@ -185,6 +223,8 @@ func (c *compiler) compile(node ast.Node) {
 		c.MapNode(n)
 	case *ast.PairNode:
 		c.PairNode(n)
+	case *ast.SpreadNode:
+		c.SpreadNode(n)
 	default:
 		panic(fmt.Sprintf("undefined node type (%T)", node))
 	}
@@ -195,6 +235,18 @@ func (c *compiler) NilNode(_ *ast.NilNode) {
 }
 
 func (c *compiler) IdentifierNode(node *ast.IdentifierNode) {
+	for i := len(c.variables) - 1; i >= 0; i-- {
+		if v := c.variables[i]; v.name == node.Value {
+			if v.local {
+				c.emit(OpLoadVar, v.slot)
+			} else if v.index {
+				c.emit(OpScopeIndex, v.scope)
+			} else {
+				c.emit(OpScopeElement, v.scope)
+			}
+			return
+		}
+	}
 	if c.mapEnv {
 		c.emit(OpLoadFast, c.addConstant(node.Value))
 	} else if len(node.FieldIndex) > 0 {
@@ -254,6 +306,10 @@ func (c *compiler) IntegerNode(node *ast.IntegerNode) {
 }
 
 func (c *compiler) FloatNode(node *ast.FloatNode) {
+	if c.decimal {
+		c.emitPush(decimal.NewFromFloat(node.Value))
+		return
+	}
 	c.emitPush(node.Value)
 }
 
@@ -269,6 +325,13 @@ func (c *compiler) StringNode(node *ast.StringNode) {
 	c.emitPush(node.Value)
 }
 
+func (c *compiler) TemplateNode(node *ast.TemplateNode) {
+	for _, part := range node.Parts {
+		c.compile(part)
+	}
+	c.emit(OpConcat, len(node.Parts))
+}
+
 func (c *compiler) ConstantNode(node *ast.ConstantNode) {
 	c.emitPush(node.Value)
 }
@@ -315,6 +378,15 @@ func (c *compiler) BinaryNode(node *ast.BinaryNode) {
 		c.emit(OpEqual)
 		c.emit(OpNot)
 
+	case "??":
+		c.compile(node.Left)
+		fallback := c.emit(OpJumpIfNil, placeholder)
+		end := c.emit(OpJump, placeholder)
+		c.patchJump(fallback)
+		c.emit(OpPop)
+		c.compile(node.Right)
+		c.patchJump(end)
+
 	case "or", "||":
 		c.compile(node.Left)
 		end := c.emit(OpJumpIfTrue, placeholder)
@@ -367,13 +439,47 @@ func (c *compiler) BinaryNode(node *ast.BinaryNode) {
 	case "/":
 		c.compile(node.Left)
 		c.compile(node.Right)
-		c.emit(OpDivide)
+		if c.decimal && !typing.IsDuration(node.Left.Type()) {
+			c.emit(OpDecimalDivide)
+		} else {
+			c.emit(OpDivide)
+		}
 
 	case "%":
 		c.compile(node.Left)
 		c.compile(node.Right)
 		c.emit(OpModulo)
 
+	case "div":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpIntDivide)
+
+	case "&":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpBitwiseAnd)
+
+	case "|":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpBitwiseOr)
+
+	case "xor":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpBitwiseXor)
+
+	case "<<":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpShiftLeft)
+
+	case ">>":
+		c.compile(node.Left)
+		c.compile(node.Right)
+		c.emit(OpShiftRight)
+
 	case "**", "^":
 		c.compile(node.Left)
 		c.compile(node.Right)
@@ -431,6 +537,11 @@ func (c *compiler) ChainNode(node *ast.ChainNode) {
 }
 
 func (c *compiler) MemberNode(node *ast.MemberNode) {
+	if node.Optional && len(c.chains) == 0 {
+		// The parser wraps optional chains, trees built otherwise may not.
+		c.ChainNode(&ast.ChainNode{Node: node})
+		return
+	}
 	if node.Method {
 		c.compile(node.Node)
 		c.emit(OpMethod, c.addConstant(&runtime.Method{
@@ -482,7 +593,11 @@ func (c *compiler) MemberNode(node *ast.MemberNode) {
 
 	if op == OpFetch {
 		c.compile(node.Property)
-		c.emit(OpFetch)
+		if node.Optional {
+			c.emit(OpFetchOptional)
+		} else {
+			c.emit(OpFetch)
+		}
 	} else {
 		c.emitLocation(node.Location(), op, c.addConstant(
 			&runtime.Field{Index: index, Path: path},
@@ -499,6 +614,20 @@ deref:
 
 func (c *compiler) SliceNode(node *ast.SliceNode) {
 	c.compile(node.Node)
+	if node.Step != nil {
+		// Defaults of from and to depend on the sign of the step,
+		// so omitted ones are resolved at runtime.
+		for _, n := range []ast.Node{node.From, node.To} {
+			if n != nil {
+				c.compile(n)
+			} else {
+				c.emit(OpNil)
+			}
+		}
+		c.compile(node.Step)
+		c.emit(OpSliceStep)
+		return
+	}
 	if node.To != nil {
 		c.compile(node.To)
 	} else {
@@ -513,10 +642,35 @@ func (c *compiler) SliceNode(node *ast.SliceNode) {
 }
 
 func (c *compiler) CallNode(node *ast.CallNode) {
-	for _, arg := range node.Arguments {
-		c.compile(arg)
+	if node.Optional && len(c.chains) == 0 {
+		c.ChainNode(&ast.ChainNode{Node: node})
+		return
+	}
+	if node.Optional {
+		// The callee is checked before arguments are evaluated, and stored
+		// in a local slot to not evaluate it twice.
+		c.compile(node.Callee)
+		ph := c.emit(OpJumpIfNil, placeholder)
+		c.chains[len(c.chains)-1] = append(c.chains[len(c.chains)-1], ph)
+		slot := c.locals
+		c.locals++
+		c.emit(OpStore, slot)
+		for _, arg := range node.Arguments {
+			c.compile(arg)
+		}
+		c.emit(OpLoadVar, slot)
+	} else {
+		for _, arg := range node.Arguments {
+			c.compile(arg)
+		}
+		c.compile(node.Callee)
+	}
+	if len(node.Arguments) > 0 {
+		if _, ok := node.Arguments[len(node.Arguments)-1].(*ast.SpreadNode); ok {
+			c.emit(OpCallSpread, len(node.Arguments))
+			return
+		}
 	}
-	c.compile(node.Callee)
 	if node.Typed > 0 {
 		c.emit(OpCallTyped, node.Typed)
 		return
@@ -528,131 +682,34 @@ func (c *compiler) CallNode(node *ast.CallNode) {
 }
 
 func (c *compiler) BuiltinNode(node *ast.BuiltinNode) {
-
-	if node.Namespace == "" {
-		c.namespaceStandard(node)
-	} else if node.Namespace == "math" {
-		c.namespaceMath(node)
-	} else {
-		panic(fmt.Sprintf("unknown builtin %v", node.Name))
+	space, ok := c.namespaces.Get(node.Namespace)
+	if !ok {
+		panic(fmt.Sprintf("unknown builtin namespace %v", node.Namespace))
 	}
-
-}
-
-func (c *compiler) namespaceStandard(node *ast.BuiltinNode) {
-	switch node.Name {
-	case "len":
-		c.compile(node.Arguments[0])
-		c.emit(OpLen)
-		c.emit(OpRot)
-		c.emit(OpPop)
-
-	case "all":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		var loopBreak int
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			loopBreak = c.emit(OpJumpIfFalse, placeholder)
-			c.emit(OpPop)
-		})
-		c.emit(OpTrue)
-		c.patchJump(loopBreak)
-		c.emit(OpEnd)
-
-	case "none":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		var loopBreak int
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			c.emit(OpNot)
-			loopBreak = c.emit(OpJumpIfFalse, placeholder)
-			c.emit(OpPop)
-		})
-		c.emit(OpTrue)
-		c.patchJump(loopBreak)
-		c.emit(OpEnd)
-
-	case "any":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		var loopBreak int
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			loopBreak = c.emit(OpJumpIfTrue, placeholder)
-			c.emit(OpPop)
-		})
-		c.emit(OpFalse)
-		c.patchJump(loopBreak)
-		c.emit(OpEnd)
-
-	case "one":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			c.emitCond(func() {
-				c.emit(OpIncrementCount)
-			})
-		})
-		c.emit(OpGetCount)
-		c.emitPush(1)
-		c.emit(OpEqual)
-		c.emit(OpEnd)
-
-	case "filter":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			c.emitCond(func() {
-				c.emit(OpIncrementCount)
-				c.emit(OpPointer)
-			})
-		})
-		c.emit(OpGetCount)
-		c.emit(OpEnd)
-		c.emit(OpArray)
-
-	case "map":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-		})
-		c.emit(OpGetLen)
-		c.emit(OpEnd)
-		c.emit(OpArray)
-
-	case "count":
-		c.compile(node.Arguments[0])
-		c.emit(OpBegin)
-		c.emitLoop(func() {
-			c.compile(node.Arguments[1])
-			c.emitCond(func() {
-				c.emit(OpIncrementCount)
-			})
-		})
-		c.emit(OpGetCount)
-		c.emit(OpEnd)
-
-	default:
-		panic(fmt.Sprintf("unknown builtin %v", node.Name))
+	member, ok := space.Get(node.Name)
+	if !ok {
+		panic(fmt.Sprintf("unknown builtin %v", node))
 	}
-}
 
-func (c *compiler) namespaceMath(node *ast.BuiltinNode) {
-	switch node.Name {
+	switch m := member.(type) {
+	case builtin.Compilable:
+		m.Compile(c, node)
 
-	case "abs":
-		c.compile(node.Arguments[0])
-		c.emit(OpAbs)
-		c.emit(OpRot)
-		c.emit(OpPop)
+	case builtin.Runnable:
+		for _, arg := range node.Arguments {
+			c.compile(arg)
+		}
+		c.emit(OpCallBuiltin, c.addConstant(&runtime.Builtin{
+			Name:  node.String(),
+			Arity: len(node.Arguments),
+			Func:  m.Run,
+		}))
+
+	case builtin.Constant:
+		c.emitPush(m.GetValue())
 
 	default:
-		panic(fmt.Sprintf("unknown builtin %v", node.Name))
+		panic(fmt.Sprintf("builtin %v cannot be compiled", node))
 	}
 }
 
@@ -679,14 +736,124 @@ func (c *compiler) emitLoop(body func()) {
 	c.patchJump(end)
 }
 
+// Compile, Emit, EmitPush, AddConstant, EmitJump, PatchJump, EmitLoop and
+// EmitCond implement builtin.Emitter for members compiling themselves.
+
+func (c *compiler) Compile(node ast.Node) {
+	c.compile(node)
+}
+
+func (c *compiler) Emit(op Opcode, args ...int) int {
+	return c.emit(op, args...)
+}
+
+func (c *compiler) EmitPush(value interface{}) int {
+	return c.emitPush(value)
+}
+
+func (c *compiler) AddConstant(constant interface{}) int {
+	return c.addConstant(constant)
+}
+
+func (c *compiler) EmitJump(op Opcode) int {
+	return c.emit(op, placeholder)
+}
+
+func (c *compiler) PatchJump(placeholder int) {
+	c.patchJump(placeholder)
+}
+
+func (c *compiler) EmitLoop(body func()) {
+	c.emitLoop(body)
+}
+
+func (c *compiler) EmitCond(body func()) {
+	c.emitCond(body)
+}
+
+func (c *compiler) CompileReducer(closure ast.Node) {
+	c.reducers = append(c.reducers, c.scopes-1)
+	c.compile(closure)
+	c.reducers = c.reducers[:len(c.reducers)-1]
+}
+
 func (c *compiler) ClosureNode(node *ast.ClosureNode) {
+	size := len(c.variables)
+	for i, name := range node.Params {
+		c.variables = append(c.variables, variable{name: name, scope: c.scopes - 1, index: i == 1})
+	}
 	c.compile(node.Node)
+	c.variables = c.variables[:size]
 }
 
 func (c *compiler) PointerNode(node *ast.PointerNode) {
+	if node.Name == "acc" {
+		if len(c.reducers) > 0 {
+			c.emit(OpScopeAcc, c.reducers[len(c.reducers)-1])
+		} else {
+			c.emit(OpGetAcc)
+		}
+		return
+	}
 	c.emit(OpPointer)
 }
 
+func (c *compiler) VariableDeclaratorNode(node *ast.VariableDeclaratorNode) {
+	c.compile(node.Value)
+	slot := c.locals
+	c.locals++
+	c.emit(OpStore, slot)
+	c.variables = append(c.variables, variable{name: node.Name, local: true, slot: slot})
+	c.compile(node.Expr)
+	c.variables = c.variables[:len(c.variables)-1]
+}
+
+// SwitchNode compiles to a chain of comparisons of the switch subject, which
+// is stored in a local slot, with the values of the cases.
+func (c *compiler) SwitchNode(node *ast.SwitchNode) {
+	c.compile(node.Node)
+	slot := c.locals
+	c.locals++
+	c.emit(OpStore, slot)
+
+	c.variables = append(c.variables, variable{name: ast.SwitchSubject, local: true, slot: slot})
+	ends := make([]int, 0, len(node.Cases))
+	for _, cs := range node.Cases {
+		next := c.CaseNode(cs.(*ast.CaseNode))
+		ends = append(ends, c.emit(OpJump, placeholder))
+		c.patchJump(next)
+	}
+	c.variables = c.variables[:len(c.variables)-1]
+
+	if node.Default != nil {
+		c.compile(node.Default)
+	} else {
+		c.emit(OpNil)
+	}
+	for _, end := range ends {
+		c.patchJump(end)
+	}
+}
+
+// CaseNode compiles the case and returns the jump to the next case, which
+// is taken if none of the conditions matched.
+func (c *compiler) CaseNode(node *ast.CaseNode) int {
+	matched := make([]int, 0, len(node.Conditions))
+	for _, cond := range node.Conditions {
+		c.compile(cond)
+		matched = append(matched, c.emit(OpJumpIfTrue, placeholder))
+		c.emit(OpPop)
+	}
+	next := c.emit(OpJump, placeholder)
+
+	for _, m := range matched {
+		c.patchJump(m)
+	}
+	c.emit(OpPop)
+	c.compile(node.Expr)
+	return next
+}
+
 func (c *compiler) ConditionalNode(node *ast.ConditionalNode) {
 	c.compile(node.Cond)
 	otherwise := c.emit(OpJumpIfFalse, placeholder)
@@ -707,6 +874,10 @@ func (c *compiler) ArrayNode(node *ast.ArrayNode) {
 		c.compile(node)
 	}
 
+	if mask, ok := spreads(node.Nodes); ok {
+		c.emit(OpArraySpread, c.addConstant(mask))
+		return
+	}
 	c.emitPush(len(node.Nodes))
 	c.emit(OpArray)
 }
@@ -716,6 +887,10 @@ func (c *compiler) MapNode(node *ast.MapNode) {
 		c.compile(pair)
 	}
 
+	if mask, ok := spreads(node.Pairs); ok {
+		c.emit(OpMapSpread, c.addConstant(mask))
+		return
+	}
 	c.emitPush(len(node.Pairs))
 	c.emit(OpMap)
 }
@@ -725,6 +900,23 @@ func (c *compiler) PairNode(node *ast.PairNode) {
 	c.compile(node.Value)
 }
 
+func (c *compiler) SpreadNode(node *ast.SpreadNode) {
+	c.compile(node.Node)
+}
+
+// spreads returns a mask of spread nodes, and false if there are none.
+func spreads(nodes []ast.Node) ([]bool, bool) {
+	mask := make([]bool, len(nodes))
+	found := false
+	for i, node := range nodes {
+		if _, ok := node.(*ast.SpreadNode); ok {
+			mask[i] = true
+			found = true
+		}
+	}
+	return mask, found
+}
+
 func kind(node ast.Node) reflect.Kind {
 	t := node.Type()
 	if t == nil {
@@ -229,6 +229,60 @@ func TestCompile(t *testing.T) {
 				Arguments: []int{0, 1, 0, 2},
 			},
 		},
+		{
+			`A.Map?.["B"]`,
+			vm.Program{
+				Constants: []interface{}{
+					&runtime.Field{
+						Index: []int{0, 2},
+						Path:  []string{"A", "Map"},
+					},
+					"B",
+				},
+				Bytecode: []vm.Opcode{
+					vm.OpLoadField,
+					vm.OpJumpIfNil,
+					vm.OpPush,
+					vm.OpFetchOptional,
+				},
+				Arguments: []int{0, 2, 1, 0},
+			},
+		},
+		{
+			`switch A.B.C.D { case 1, 2 => "a"; default => "b" }`,
+			vm.Program{
+				Constants: []interface{}{
+					&runtime.Field{
+						Index: []int{0, 1, 2, 3},
+						Path:  []string{"A", "B", "C", "D"},
+					},
+					1,
+					2,
+					"a",
+					"b",
+				},
+				Bytecode: []vm.Opcode{
+					vm.OpLoadField,
+					vm.OpStore,
+					vm.OpLoadVar,
+					vm.OpPush,
+					vm.OpEqualInt,
+					vm.OpJumpIfTrue,
+					vm.OpPop,
+					vm.OpLoadVar,
+					vm.OpPush,
+					vm.OpEqualInt,
+					vm.OpJumpIfTrue,
+					vm.OpPop,
+					vm.OpJump,
+					vm.OpPop,
+					vm.OpPush,
+					vm.OpJump,
+					vm.OpPush,
+				},
+				Arguments: []int{0, 0, 0, 1, 0, 7, 0, 0, 2, 0, 2, 0, 3, 0, 3, 1, 4},
+			},
+		},
 	}
 
 	for _, test := range tests {
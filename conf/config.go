@@ -5,6 +5,7 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
 	"github.com/antonmedv/expr/vm/runtime"
 )
 
@@ -16,16 +17,19 @@ type Config struct {
 	Operators   OperatorsTable
 	Expect      reflect.Kind
 	Optimize    bool
+	Decimal     bool // float literals and division are decimal.Decimal
 	Strict      bool
 	ConstFns    map[string]reflect.Value
 	Visitors    []ast.Visitor
+	Namespaces  map[string]builtin.BuiltinNamespace
 }
 
 func New(env interface{}) *Config {
 	c := &Config{
-		Operators: make(map[string][]string),
-		ConstFns:  make(map[string]reflect.Value),
-		Optimize:  true,
+		Operators:  make(map[string][]string),
+		ConstFns:   make(map[string]reflect.Value),
+		Namespaces: make(map[string]builtin.BuiltinNamespace),
+		Optimize:   true,
 	}
 	c.WithEnv(env)
 	return c
@@ -76,3 +80,17 @@ func (c *Config) ConstExpr(name string) {
 	}
 	c.ConstFns[name] = fn
 }
+
+func (c *Config) Namespace(namespace builtin.BuiltinNamespace) {
+	name := namespace.Name()
+	if name == "" {
+		panic("builtin namespace must have a name")
+	}
+	if _, ok := c.Namespaces[name]; ok {
+		panic(fmt.Errorf("builtin namespace %s is already defined", name))
+	}
+	if c.Namespaces == nil {
+		c.Namespaces = make(map[string]builtin.BuiltinNamespace)
+	}
+	c.Namespaces[name] = namespace
+}
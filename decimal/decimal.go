@@ -0,0 +1,314 @@
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionPrecision is the number of digits after the decimal point kept
+// in the result of the division, which is not exact.
+const DivisionPrecision = 16
+
+// ErrDivisionByZero is the value of the panic of the division by zero.
+var ErrDivisionByZero = errors.New("decimal division by zero")
+
+// Decimal is an exact decimal number: value * 10^-scale.
+// The zero value is 0.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+var ten = big.NewInt(10)
+
+// New returns value * 10^-scale, e.g. New(1050, 2) is 10.50.
+func New(value int64, scale int32) Decimal {
+	if scale < 0 {
+		v := big.NewInt(value)
+		v.Mul(v, pow10(-scale))
+		return Decimal{value: v}
+	}
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// NewFromInt returns the integer as a decimal.
+func NewFromInt(value int64) Decimal {
+	return Decimal{value: big.NewInt(value)}
+}
+
+// NewFromFloat returns the shortest decimal, which converts back to the
+// same float, e.g. 0.1 for 0.1 (and not 0.1000000000000000055511151231257827).
+func NewFromFloat(value float64) Decimal {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		panic(fmt.Sprintf("cannot convert %v to decimal", value))
+	}
+	d, err := Parse(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Parse parses the decimal from a string such as "-12.345" or "1.5e3".
+func Parse(s string) (Decimal, error) {
+	str := s
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("cannot parse %q as decimal", s)
+		}
+		str, exp = str[:i], e
+	}
+
+	var scale int64
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		scale = int64(len(str) - i - 1)
+		str = str[:i] + str[i+1:]
+	}
+	digits := strings.TrimLeft(str, "+-")
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("cannot parse %q as decimal", s)
+	}
+
+	value, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("cannot parse %q as decimal", s)
+	}
+	scale -= exp
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+	if scale > math.MaxInt32 {
+		return Decimal{}, fmt.Errorf("cannot parse %q as decimal", s)
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+// MustParse is like Parse, but panics if the string cannot be parsed.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: a.Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: a.Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	value := new(big.Int).Mul(d.int(), d2.int())
+	return Decimal{value: value, scale: d.scale + d2.scale}
+}
+
+// Div returns d / d2 rounded half away from zero to DivisionPrecision
+// digits after the decimal point. It panics if d2 is zero.
+func (d Decimal) Div(d2 Decimal) Decimal {
+	if d2.Sign() == 0 {
+		panic(ErrDivisionByZero)
+	}
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(d2.int())
+	if shift := DivisionPrecision + d2.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Abs(r).Lsh(new(big.Int).Abs(r), 1).Cmp(new(big.Int).Abs(den)) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign()*den.Sign())))
+	}
+	return Decimal{value: q, scale: DivisionPrecision}.trim()
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// Cmp returns -1 if d < d2, 0 if d == d2 and +1 if d > d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and d2 are the same number, e.g. 1.50 and 1.5.
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// Round rounds d half away from zero to the number of digits after the
+// decimal point.
+func (d Decimal) Round(places int32) Decimal {
+	if places >= d.scale {
+		return d
+	}
+	factor := pow10(d.scale - places)
+	q, r := new(big.Int).QuoRem(d.int(), factor, new(big.Int))
+	if new(big.Int).Abs(r).Lsh(new(big.Int).Abs(r), 1).Cmp(factor) >= 0 {
+		q.Add(q, big.NewInt(int64(d.Sign())))
+	}
+	if places < 0 {
+		// Rounded to tens, hundreds, ...: Round(-2) of 1250 is 1300.
+		return Decimal{value: q.Mul(q, pow10(-places))}
+	}
+	return Decimal{value: q, scale: places}
+}
+
+// Truncate returns the integer part of d, e.g. -1.5 is truncated to -1.
+func (d Decimal) Truncate() Decimal {
+	if d.scale <= 0 {
+		return d
+	}
+	return Decimal{value: new(big.Int).Quo(d.int(), pow10(d.scale))}
+}
+
+// Floor returns the greatest integer less than or equal to d.
+func (d Decimal) Floor() Decimal {
+	t := d.Truncate()
+	if d.Sign() < 0 && !t.Equal(d) {
+		return t.Sub(NewFromInt(1))
+	}
+	return t
+}
+
+// Ceil returns the least integer greater than or equal to d.
+func (d Decimal) Ceil() Decimal {
+	t := d.Truncate()
+	if d.Sign() > 0 && !t.Equal(d) {
+		return t.Add(NewFromInt(1))
+	}
+	return t
+}
+
+// Mod returns the remainder of d / d2, which has the sign of d, like the %
+// operator of Go. It panics if d2 is zero.
+func (d Decimal) Mod(d2 Decimal) Decimal {
+	if d2.Sign() == 0 {
+		panic(ErrDivisionByZero)
+	}
+	a, b, scale := align(d, d2)
+	return Decimal{value: a.Rem(a, b), scale: scale}
+}
+
+// PowInt returns d raised to the power of n. Negative powers are computed
+// with Div, so they are rounded like the division.
+func (d Decimal) PowInt(n int) Decimal {
+	negative := n < 0
+	if negative {
+		n = -n
+	}
+	result, base := NewFromInt(1), d
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = result.Mul(base)
+		}
+		base = base.Mul(base)
+	}
+	if negative {
+		return NewFromInt(1).Div(result)
+	}
+	return result.trim()
+}
+
+// Float64 returns the nearest float to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Int64 returns the integer part of d.
+func (d Decimal) Int64() int64 {
+	return new(big.Int).Quo(d.int(), pow10(d.scale)).Int64()
+}
+
+// String formats d without trailing zeros after the decimal point, e.g. 10.5.
+func (d Decimal) String() string {
+	return d.trim().format()
+}
+
+// StringFixed formats d with exactly the number of digits after the
+// decimal point, e.g. StringFixed(2) of 10.5 is 10.50.
+func (d Decimal) StringFixed(places int32) string {
+	d = d.Round(places)
+	if d.scale < places {
+		d = Decimal{value: new(big.Int).Mul(d.int(), pow10(places-d.scale)), scale: places}
+	}
+	return d.format()
+}
+
+// MarshalJSON encodes d as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d Decimal) format() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(digits) <= int(d.scale) {
+			digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// trim removes trailing zeros after the decimal point.
+func (d Decimal) trim() Decimal {
+	value, scale := new(big.Int).Set(d.int()), d.scale
+	r := new(big.Int)
+	for scale > 0 {
+		q, _ := new(big.Int).QuoRem(value, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		value, scale = q, scale-1
+	}
+	return Decimal{value: value, scale: scale}
+}
+
+func (d Decimal) int() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// align returns the values of a and b scaled to the same scale.
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+	}
+	return x, y, a.scale
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,116 @@
+package decimal_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/antonmedv/expr/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0", "0"},
+		{"-0.0", "0"},
+		{"12.340", "12.34"},
+		{"-0.001", "-0.001"},
+		{"+7.", "7"},
+		{"1.5e3", "1500"},
+		{"15e-4", "0.0015"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789"},
+	}
+	for _, tt := range tests {
+		d, err := decimal.Parse(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, d.String(), tt.input)
+	}
+
+	for _, input := range []string{"", ".", "-", "1.2.3", "1e", "0x10", "1_000"} {
+		_, err := decimal.Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestDecimal_arithmetic(t *testing.T) {
+	a := decimal.NewFromFloat(0.1)
+	b := decimal.NewFromFloat(0.2)
+	assert.True(t, a.Add(b).Equal(decimal.MustParse("0.3")))
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.5", a.Div(b).String())
+	assert.Equal(t, "0.3333333333333333", decimal.NewFromInt(1).Div(decimal.NewFromInt(3)).String())
+	assert.Equal(t, "-0.6666666666666667", decimal.NewFromInt(-2).Div(decimal.NewFromInt(3)).String())
+	assert.Equal(t, "10.5", decimal.New(1050, 2).String())
+	assert.Equal(t, "1200", decimal.New(12, -2).String())
+	assert.Equal(t, "0", decimal.Decimal{}.Add(decimal.Decimal{}).String())
+	assert.Panics(t, func() { a.Div(decimal.Decimal{}) })
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	assert.Equal(t, 0, decimal.MustParse("1.50").Cmp(decimal.MustParse("1.5")))
+	assert.Equal(t, -1, decimal.MustParse("-2").Cmp(decimal.MustParse("1.5")))
+	assert.Equal(t, 1, decimal.MustParse("0.0001").Cmp(decimal.Decimal{}))
+}
+
+func TestDecimal_StringFixed(t *testing.T) {
+	assert.Equal(t, "10.50", decimal.MustParse("10.5").StringFixed(2))
+	assert.Equal(t, "10.13", decimal.MustParse("10.125").StringFixed(2))
+	assert.Equal(t, "-10.13", decimal.MustParse("-10.125").StringFixed(2))
+	assert.Equal(t, "0.00", decimal.MustParse("0.004").StringFixed(2))
+	assert.Equal(t, "3", decimal.MustParse("2.5").StringFixed(0))
+}
+
+func TestDecimal_conversions(t *testing.T) {
+	d := decimal.MustParse("-12.75")
+	assert.Equal(t, -12.75, d.Float64())
+	assert.Equal(t, int64(-12), d.Int64())
+
+	b, err := d.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "-12.75", string(b))
+}
+
+func TestDecimal_rounding(t *testing.T) {
+	assert.Equal(t, "12", decimal.MustParse("12.75").Truncate().String())
+	assert.Equal(t, "-12", decimal.MustParse("-12.75").Truncate().String())
+	assert.Equal(t, "12", decimal.MustParse("12.75").Floor().String())
+	assert.Equal(t, "-13", decimal.MustParse("-12.75").Floor().String())
+	assert.Equal(t, "13", decimal.MustParse("12.25").Ceil().String())
+	assert.Equal(t, "-12", decimal.MustParse("-12.25").Ceil().String())
+	assert.Equal(t, "12.8", decimal.MustParse("12.75").Round(1).String())
+	assert.Equal(t, "1200", decimal.MustParse("1234.5").Round(-2).String())
+}
+
+func TestDecimal_Mod(t *testing.T) {
+	assert.Equal(t, "1.99", decimal.MustParse("19.99").Mod(decimal.NewFromInt(2)).String())
+	assert.Equal(t, "-1.5", decimal.MustParse("-7.5").Mod(decimal.NewFromInt(2)).String())
+	assert.Panics(t, func() { decimal.NewFromInt(1).Mod(decimal.Decimal{}) })
+}
+
+func TestDecimal_PowInt(t *testing.T) {
+	assert.Equal(t, "399.6001", decimal.MustParse("19.99").PowInt(2).String())
+	assert.Equal(t, "1", decimal.MustParse("19.99").PowInt(0).String())
+	assert.Equal(t, "0.25", decimal.NewFromInt(2).PowInt(-2).String())
+}
+
+type cents int64
+
+func TestRegister(t *testing.T) {
+	assert.False(t, decimal.Registered(reflect.TypeOf(cents(0))))
+	decimal.Register(cents(0), func(v interface{}) decimal.Decimal {
+		return decimal.New(int64(v.(cents)), 2)
+	})
+	assert.True(t, decimal.Registered(reflect.TypeOf(cents(0))))
+	assert.True(t, decimal.Registered(reflect.TypeOf(decimal.Decimal{})))
+
+	d, ok := decimal.From(cents(1999))
+	require.True(t, ok)
+	assert.Equal(t, "19.99", d.String())
+
+	_, ok = decimal.From(1999)
+	assert.False(t, ok)
+}
@@ -0,0 +1,41 @@
+package decimal
+
+import (
+	"reflect"
+)
+
+var converters = map[reflect.Type]func(v interface{}) Decimal{}
+
+// Register makes values of the type of example usable as decimals, e.g.
+// decimals of another package. They are converted to Decimal with fn in
+// arithmetic, comparison and functions of the math namespace, and results
+// of these operations are Decimal. Register is not safe for concurrent use,
+// so it should be called on initialization, before compiling expressions.
+func Register(example interface{}, fn func(v interface{}) Decimal) {
+	converters[reflect.TypeOf(example)] = fn
+}
+
+// Registered reports whether values of the type are decimals: Decimal or
+// a type added with Register.
+func Registered(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t == reflect.TypeOf(Decimal{}) {
+		return true
+	}
+	_, ok := converters[t]
+	return ok
+}
+
+// From returns the value as Decimal, if it is a decimal. Values of types
+// added with Register are converted.
+func From(v interface{}) (Decimal, bool) {
+	if d, ok := v.(Decimal); ok {
+		return d, true
+	}
+	if fn, ok := converters[reflect.TypeOf(v)]; ok {
+		return fn(v), true
+	}
+	return Decimal{}, false
+}
@@ -3,12 +3,16 @@ package expr
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
 	"github.com/antonmedv/expr/checker"
 	"github.com/antonmedv/expr/compiler"
 	"github.com/antonmedv/expr/conf"
 	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/namespaces"
+	"github.com/antonmedv/expr/namespaces/lib_time"
 	"github.com/antonmedv/expr/optimizer"
 	"github.com/antonmedv/expr/parser"
 	"github.com/antonmedv/expr/vm"
@@ -110,6 +114,15 @@ func AsFloat64() Option {
 	}
 }
 
+// DecimalNumbers makes float literals and the division exact decimals
+// (decimal.Decimal), e.g. 0.1 + 0.2 == 0.3 is true. Floats and integers
+// are converted to decimals in arithmetic with decimals.
+func DecimalNumbers() Option {
+	return func(c *conf.Config) {
+		c.Decimal = true
+	}
+}
+
 // Optimize turns optimizations on or off.
 func Optimize(b bool) Option {
 	return func(c *conf.Config) {
@@ -124,18 +137,105 @@ func Patch(visitor ast.Visitor) Option {
 	}
 }
 
+// Namespace makes a builtin namespace available to the compiled expression.
+// Namespace is visible only to the compilation it was passed to. Names of
+// the default namespaces (like math) cannot be redefined.
+func Namespace(namespace builtin.BuiltinNamespace) Option {
+	return func(c *conf.Config) {
+		if _, ok := namespaces.Get(namespace.Name()); ok {
+			panic(fmt.Errorf("builtin namespace %s cannot be redefined", namespace.Name()))
+		}
+		c.Namespace(namespace)
+	}
+}
+
+// Clock sets the source of time.now(). A fixed clock makes results of
+// expressions using the current time reproducible. The time namespace is
+// then available even if the environment has a variable named time.
+func Clock(now func() time.Time) Option {
+	return func(c *conf.Config) {
+		c.Namespace(lib_time.NewBuiltinTimeWithClock(now))
+	}
+}
+
 // Compile parses and compiles given input expression to bytecode program.
+//
+// Type errors are reported all at once: a single error is *file.Error, and
+// several errors are file.Errors. Use file.AsErrors to get the list in both
+// cases. The parser recovers from syntax errors only in the elements of
+// arrays, maps, arguments and blocks, elsewhere it stops at the first one.
 func Compile(input string, ops ...Option) (*vm.Program, error) {
+	config := newConfig(ops)
+
+	tree, err := parser.ParseWithConfig(input, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(tree, config)
+}
+
+// CompileTree compiles the tree to bytecode program, like Compile does for
+// the parsed input. The tree is checked and optimized in place, arguments of
+// builtins are validated as the parser does. Trees from parser.NewTree have
+// no source, so errors have no snippets.
+func CompileTree(tree *parser.Tree, ops ...Option) (*vm.Program, error) {
+	config := newConfig(ops)
+
+	// The parser ensures arguments of builtins match their declaration,
+	// which the checker relies on, so trees built otherwise are checked.
+	arguments := &builtinArguments{namespaces: namespaces.With(config.Namespaces)}
+	ast.Walk(&tree.Node, arguments)
+	if err := arguments.errors.Bind(tree.Source).Err(); err != nil {
+		return nil, err
+	}
+
+	return compile(tree, config)
+}
+
+// builtinArguments checks arguments of the builtin calls of the tree.
+// Unknown builtins are left to the checker.
+type builtinArguments struct {
+	namespaces namespaces.Table
+	errors     file.Errors
+}
+
+func (b *builtinArguments) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.BuiltinNode)
+	if !ok {
+		return
+	}
+	space, ok := b.namespaces.Get(n.Namespace)
+	if !ok {
+		return
+	}
+	member, ok := space.Get(n.Name)
+	if !ok || !member.Callable() {
+		return
+	}
+	if function, ok := member.(builtin.Function); ok {
+		if err := builtin.CheckArguments(function, n); err != nil {
+			b.errors = append(b.errors, err)
+		}
+	}
+}
+
+func newConfig(ops []Option) *conf.Config {
 	config := &conf.Config{
-		Operators: make(map[string][]string),
-		ConstFns:  make(map[string]reflect.Value),
-		Optimize:  true,
+		Operators:  make(map[string][]string),
+		ConstFns:   make(map[string]reflect.Value),
+		Namespaces: make(map[string]builtin.BuiltinNamespace),
+		Optimize:   true,
 	}
 
 	for _, op := range ops {
 		op(config)
 	}
 
+	return config
+}
+
+func compile(tree *parser.Tree, config *conf.Config) (*vm.Program, error) {
 	if len(config.Operators) > 0 {
 		config.Visitors = append(config.Visitors, &conf.OperatorPatcher{
 			Operators: config.Operators,
@@ -143,11 +243,6 @@ func Compile(input string, ops ...Option) (*vm.Program, error) {
 		})
 	}
 
-	tree, err := parser.Parse(input)
-	if err != nil {
-		return nil, err
-	}
-
 	if len(config.Visitors) > 0 {
 		for _, v := range config.Visitors {
 			// We need to perform types check, because some visitors may rely on
@@ -155,19 +250,19 @@ func Compile(input string, ops ...Option) (*vm.Program, error) {
 			_, _ = checker.Check(tree, config)
 			ast.Walk(&tree.Node, v)
 		}
-		_, err = checker.Check(tree, config)
+		_, err := checker.Check(tree, config)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		_, err = checker.Check(tree, config)
+		_, err := checker.Check(tree, config)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if config.Optimize {
-		err = optimizer.Optimize(&tree.Node, config)
+		err := optimizer.Optimize(&tree.Node, config)
 		if err != nil {
 			if fileError, ok := err.(*file.Error); ok {
 				return nil, fileError.Bind(tree.Source)
@@ -184,6 +279,26 @@ func Compile(input string, ops ...Option) (*vm.Program, error) {
 	return program, nil
 }
 
+// Format parses given input expression and prints it back in the canonical
+// form. Visitors of Patch options are applied before printing, so Format can
+// be used to migrate expressions.
+func Format(input string, ops ...Option) (string, error) {
+	config := newConfig(ops)
+
+	tree, err := parser.ParseWithConfig(input, config)
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range config.Visitors {
+		// Visitors may rely on types information available in the tree.
+		_, _ = checker.Check(tree, config)
+		ast.Walk(&tree.Node, v)
+	}
+
+	return ast.Print(tree.Node), nil
+}
+
 // Run evaluates given bytecode program.
 func Run(program *vm.Program, env interface{}) (interface{}, error) {
 	return vm.Run(program, env)
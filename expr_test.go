@@ -3,6 +3,7 @@ package expr_test
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -10,7 +11,12 @@ import (
 
 	"github.com/antonmedv/expr"
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/decimal"
 	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/namespaces/lib_math"
+	"github.com/antonmedv/expr/parser"
+	"github.com/antonmedv/expr/test/mock"
+	"github.com/antonmedv/expr/vm/runtime"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -475,6 +481,26 @@ func ExamplePatch() {
 	// Output : Hello, you, world!
 }
 
+func ExampleFormat() {
+	output, err := expr.Format(`(Price+Fee)*2 > 100  and not(Tag in ["a","b"])`)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+	fmt.Println(output)
+
+	output, err = expr.Format(`greet.you.world + "!"`, expr.Patch(&patcher{}))
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+	fmt.Println(output)
+
+	// Output:
+	// (Price + Fee) * 2 > 100 and Tag not in ["a", "b"]
+	// get(get(greet, "you"), "world") + "!"
+}
+
 func TestOperator_struct(t *testing.T) {
 	env := &mockEnv{
 		BirthDay: time.Date(2017, time.October, 23, 18, 30, 0, 0, time.UTC),
@@ -919,6 +945,110 @@ func TestExpr(t *testing.T) {
 			`Variadic("empty")`,
 			[]int{},
 		},
+		{
+			`Variadic("head", Array...)`,
+			[]int{1, 2, 3, 4, 5},
+		},
+		{
+			`Variadic("head", [1, 2]...)`,
+			[]int{1, 2},
+		},
+		{
+			`Concat(Array[:3]...)`,
+			"123",
+		},
+		{
+			`[...Array, 6]`,
+			[]interface{}{1, 2, 3, 4, 5, 6},
+		},
+		{
+			`[0, ...Array[:2], ...[], ...Segments[0:1] |> map({.Origin})]`,
+			[]interface{}{0, 1, 2, "MOW"},
+		},
+		{
+			`{...{a: 1, b: 2}, b: 3}`,
+			map[string]interface{}{"a": 1, "b": 3},
+		},
+		{
+			`{a: 0, ...{a: 1}}`,
+			map[string]interface{}{"a": 1},
+		},
+		{
+			`Array[-1] + Array[-5]`,
+			6,
+		},
+		{
+			`[1, 2, 3][-1]`,
+			3,
+		},
+		{
+			`String[-1] + String[0]`,
+			"gs",
+		},
+		{
+			`Array[-2:]`,
+			[]int{4, 5},
+		},
+		{
+			`Array[-10:-3]`,
+			[]int{1, 2},
+		},
+		{
+			`Array[::2]`,
+			[]int{1, 3, 5},
+		},
+		{
+			`Array[::-1]`,
+			[]int{5, 4, 3, 2, 1},
+		},
+		{
+			`Array[3:0:-1]`,
+			[]int{4, 3, 2},
+		},
+		{
+			`Array[-2::-2]`,
+			[]int{4, 2},
+		},
+		{
+			`String[::-1]`,
+			"gnirts",
+		},
+		{
+			`("héllo")[::-1] + ("héllo")[1::2] + ("héllo")[1:3]`,
+			"olléhélél",
+		},
+		{
+			`[("héllo")[1], ("héllo")[-1], ("héllo")[0:3], ("héllo")[0:3:1]]`,
+			[]interface{}{"é", "o", "hél", "hél"},
+		},
+		{
+			`int("42") + int(" 7 ") + int(2.9) + int(Bool)`,
+			52,
+		},
+		{
+			`float("2.5") * Three + float(Three)`,
+			10.5,
+		},
+		{
+			`int(OneDayDuration) / int(1h)`,
+			24.0,
+		},
+		{
+			`string(Ticket.Price) + "$" + string(1.5)`,
+			"100$1.5",
+		},
+		{
+			`bool("false") || bool(Int64) || !bool("1")`,
+			false,
+		},
+		{
+			`type(Array) + type(1.5) + type(nil) + type(Three) + type(OneDayDuration) + type(Any)`,
+			"arrayfloatnilintdurationstring",
+		},
+		{
+			`"42" |> int() + 1`,
+			43,
+		},
 		{
 			`String[:]`,
 			"string",
@@ -999,6 +1129,326 @@ func TestExpr(t *testing.T) {
 			`lowercase`,
 			"lowercase",
 		},
+		{
+			`math.abs(-Three)`,
+			3,
+		},
+		{
+			`math.abs(-2.5) + math.abs(Float64)`,
+			2.5,
+		},
+		{
+			`math.floor(2.7) + math.ceil(2.2) + math.trunc(-1.5)`,
+			4.0,
+		},
+		{
+			`math.floor(Three)`,
+			3,
+		},
+		{
+			`math.round(3.14159, 2)`,
+			3.14,
+		},
+		{
+			`math.round(2.5) == 3 && math.round(1234, -2) == 1200`,
+			true,
+		},
+		{
+			`math.sqrt(16) + math.pow(2, Three) + math.log10(100)`,
+			14.0,
+		},
+		{
+			`math.min(Three, 1, 2)`,
+			1,
+		},
+		{
+			`math.max(Three, 4.5)`,
+			4.5,
+		},
+		{
+			`math.clamp(Three * 10, 0, 10)`,
+			10,
+		},
+		{
+			`[math.sign(-Three), math.sign(0), math.sign(0.5)]`,
+			[]interface{}{-1, 0, 1},
+		},
+		{
+			`math.pi > 3.14 && math.e < 2.72 && math.inf > math.maxInt && math.minInt < 0`,
+			true,
+		},
+		{
+			`math.nan != math.nan`,
+			true,
+		},
+		{
+			`math.cos(0) + math.sin(0) + math.tan(0) + math.exp(0) + math.log(1)`,
+			2.0,
+		},
+		{
+			`math.asin(0) + math.acos(1) + math.atan(0) + math.atan2(0, 1)`,
+			0.0,
+		},
+		{
+			`strings.upper(String) + strings.lower("ABC") + strings.title("hello wORLD")`,
+			"STRINGabcHello WORLD",
+		},
+		{
+			`strings.trim("  a b  ") + strings.trim("--a--", "-") + strings.trimPrefix(String, "str") + strings.trimSuffix(String, "ing")`,
+			"a baingstr",
+		},
+		{
+			`strings.split("a,b,c", ",")`,
+			[]string{"a", "b", "c"},
+		},
+		{
+			`strings.join(strings.split(String, "i"), "-") + strings.join(["a", "b"])`,
+			"str-ngab",
+		},
+		{
+			`strings.replace(lowercase, "e", "E")`,
+			"lowErcasE",
+		},
+		{
+			`[strings.indexOf("héllo", "l"), strings.lastIndexOf("héllo", "l"), strings.indexOf(String, "x")]`,
+			[]interface{}{2, 3, -1},
+		},
+		{
+			`strings.repeat("ab", Three)`,
+			"ababab",
+		},
+		{
+			`strings.padLeft("7", Three, "0") + strings.padRight("a", 4, "xy") + strings.padLeft(String, 2)`,
+			"007axyxstring",
+		},
+		{
+			`strings.length("héllo") == 5 && len("héllo") == 6`,
+			true,
+		},
+		{
+			`time.format(time.date(2017, 10, 23), "2006-01-02") + time.format(time.parse("15:04", "09:30"), " 3PM")`,
+			"2017-10-23 9AM",
+		},
+		{
+			`time.addDate(BirthDay, 0, 1, 1) - BirthDay == time.duration("768h")`,
+			true,
+		},
+		{
+			`time.startOfDay(BirthDay) == time.truncate(BirthDay, time.duration("24h")) && time.now() > Now`,
+			true,
+		},
+		{
+			`time.weekday(time.date(2017, 10, 23))`,
+			1,
+		},
+		{
+			`time.format(time.inLocation(time.fromUnix(0), "Europe/Berlin"), "15:04 MST")`,
+			"01:00 CET",
+		},
+		{
+			`[time.unix(time.fromUnix(86400)), time.unixMilli(time.fromUnixMilli(1500))]`,
+			[]interface{}{86400, 1500},
+		},
+		{
+			`OneDayDuration > 23h59m && OneDayDuration == 12h + 720m && -1h < 0s`,
+			true,
+		},
+		{
+			`BirthDay - d"2017-10-23" == 18h30m`,
+			true,
+		},
+		{
+			`[1h * 2, 2 * 30m, 1h * 1.5, 1h / 4, 90m / 1h]`,
+			[]interface{}{2 * time.Hour, time.Hour, 90 * time.Minute, 15 * time.Minute, 1.5},
+		},
+		{
+			`BirthDay == t"2017-10-23T20:30:00+02:00"`,
+			true,
+		},
+		{
+			`reduce(Array, {#acc + #}, 10)`,
+			25,
+		},
+		{
+			`reduce(Segments, {#acc + " " + .Destination}, "")`,
+			" LED MOW",
+		},
+		{
+			`reduce(Array, {#acc * #})`,
+			120,
+		},
+		{
+			`map(Array, {reduce(Array, {#acc + #}, #)})`,
+			[]interface{}{16, 17, 18, 19, 20},
+		},
+		{
+			`reduce(Array, {#acc + # / 2}, 0)`,
+			7.5,
+		},
+		{
+			`reduce(Array, {#acc + sum(map(Array, {#acc}))}, 1)`,
+			7776,
+		},
+		{
+			`[sum(Array), sum(Segments, {len(.Origin)}), avg(Array), avg([1, 2.5])]`,
+			[]interface{}{15, 6, 3.0, 1.75},
+		},
+		{
+			`[min(Array), max(Array), min(Segments, {.Destination})]`,
+			[]interface{}{1, 5, "LED"},
+		},
+		{
+			`map(sortBy(Segments, {.Destination}), {.Origin})`,
+			[]interface{}{"MOW", "LED"},
+		},
+		{
+			`sortBy(Array, {-#})`,
+			[]int{5, 4, 3, 2, 1},
+		},
+		{
+			`groupBy(Array, {# % 2 == 0})[true]`,
+			[]interface{}{2, 4},
+		},
+		{
+			`[uniq([1, 2, 1, 3, 2]), uniq(Array, {# % 3})]`,
+			[]interface{}{[]interface{}{1, 2, 3}, []int{1, 2, 3}},
+		},
+		{
+			`flatten([[1, 2], 3, [[4]]])`,
+			[]interface{}{1, 2, 3, []interface{}{4}},
+		},
+		{
+			`[first(Array), last(Array)]`,
+			[]interface{}{1, 5},
+		},
+		{
+			`[find(Array, {# > 2}), find(Array, {# > 5}), find(Segments, {.Origin == "LED"}).Destination]`,
+			[]interface{}{3, nil, "MOW"},
+		},
+		{
+			`[findIndex(Array, {# > 2}), findIndex(Array, {# > 5})]`,
+			[]interface{}{2, -1},
+		},
+		{
+			`[take(Array, 2), drop(Array, 3), take(Array, 10), drop(Array, 10)]`,
+			[]interface{}{[]int{1, 2}, []int{4, 5}, []int{1, 2, 3, 4, 5}, []int{}},
+		},
+		{
+			`zip(Array, ["a", "b"])`,
+			[][]interface{}{{1, "a"}, {2, "b"}},
+		},
+		{
+			`filter(Array, x => x > 3)`,
+			[]interface{}{4, 5},
+		},
+		{
+			`map(Segments, (s, i) => [i, s.Destination])`,
+			[]interface{}{[]interface{}{0, "LED"}, []interface{}{1, "MOW"}},
+		},
+		{
+			`all(Segments, s => any(Segments, t => t.Origin == s.Destination))`,
+			true,
+		},
+		{
+			`map(Segments, s => count(Array, (x, i) => x > i && s.Origin == "LED"))`,
+			[]interface{}{0, 5},
+		},
+		{
+			`[NilInt ?? 5, Nil ?? nil ?? "x", Ticket?.Price ?? 0, String ?? "default"]`,
+			[]interface{}{5, "x", 100, "string"},
+		},
+		{
+			`[Array?.[0], Array?.[10], Ticket?.Price, Segments?.[1]?.Origin, Segments?.[5]?.Origin]`,
+			[]interface{}{1, nil, 100, "LED", nil},
+		},
+		{
+			`[Inc?.(1), Nil?.(1), Ticket.Price + (Array?.[9] ?? 1)]`,
+			[]interface{}{2, nil, 101},
+		},
+		{
+			`Segments |> sortBy({.Destination}) |> map({.Origin}) |> strings.join("-")`,
+			"MOW-LED",
+		},
+		{
+			`Array |> filter(x => x % 2 == 1) |> sum() |> Inc()`,
+			10,
+		},
+		{
+			`len(NilSlice ?? Array)`,
+			5,
+		},
+		{
+			`let big = filter(Array, {# > 2}); len(big) == 3 && sum(big) == 12`,
+			true,
+		},
+		{
+			`let x = Three; let y = x * 2; map(Segments, s => let n = len(s.Origin); n + x + y)`,
+			[]interface{}{12, 12},
+		},
+		{
+			`[all({a: 1, b: 2}, {.Value > 0}), any({a: 1}, {.Key == "b"}), none({a: 1}, {.Value > 1}), one({a: 1, b: 2}, {.Value > 1})]`,
+			[]interface{}{true, false, true, true},
+		},
+		{
+			`[count({a: 1, b: 2, c: 3}, {.Value > 1}), len(filter({a: 1, b: 2}, {.Key != "a"}))]`,
+			[]interface{}{2, 1},
+		},
+		{
+			`map({b: "2", a: "1", c: "3"}, {#.Key + .Value})`,
+			[]interface{}{"a1", "b2", "c3"},
+		},
+		{
+			`[keys({b: 2, a: 1}), values({b: 2, a: 1}), entries({a: 1})[0].Key]`,
+			[]interface{}{[]string{"a", "b"}, []interface{}{1, 2}, "a"},
+		},
+		{
+			"`${Segments[0].Origin}-${Segments[0].Destination}: ${len(Segments)} segments, ${Ticket.Price * 1.5}, ${Nil}`",
+			"MOW-LED: 2 segments, 150, <nil>",
+		},
+		{
+			"map(Segments, {`${.Origin} \\${x} ${`${# != nil}`}`})",
+			[]interface{}{"MOW ${x} true", "LED ${x} true"},
+		},
+		{
+			"`a` + `b${`c`}`",
+			"abc",
+		},
+		{
+			`[Ticket.Price div 3, -7 div 2, Ticket.Price div Three, 7 div 2]`,
+			[]interface{}{33, -3, 33, 3},
+		},
+		{
+			`[Ticket.Price & 7, Ticket.Price | 3, Ticket.Price xor 96, 1 << Three, Ticket.Price >> 2]`,
+			[]interface{}{4, 103, 4, 8, 25},
+		},
+		{
+			`Int64 + 6 & Int32 + 3 | 1 << 2`,
+			6,
+		},
+		{
+			`(Ticket.Price / 2 == 50) && Ticket.Price / 8 == 12.5`,
+			true,
+		},
+		{
+			`Array |> sum() & 7`,
+			7,
+		},
+		{
+			`map(1..10, {switch # { case 1, 2 => "low"; case 3..5 => "mid"; case Three * 2 => "six"; default => "high" }})`,
+			[]interface{}{"low", "low", "mid", "mid", "mid", "six", "high", "high", "high", "high"},
+		},
+		{
+			`map(Segments, {switch #.Origin { case matches "^M" => "Moscow"; case "LED" => "Saint Petersburg" }})`,
+			[]interface{}{"Moscow", "Saint Petersburg"},
+		},
+		{
+			`switch Ticket.Price { case 200 => "expensive" }`,
+			nil,
+		},
+		{
+			`let p = Ticket.Price; switch p - 50 { case p => 1; case p - 50 => switch p { case 100 => 2; default => 3 }; default => 4 }`,
+			2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1030,6 +1480,152 @@ func TestExpr(t *testing.T) {
 	}
 }
 
+func TestExpr_decimal_numbers(t *testing.T) {
+	type Order struct {
+		Price    decimal.Decimal
+		Quantity int
+		Discount float64
+	}
+	env := map[string]interface{}{
+		"Order": Order{Price: decimal.MustParse("19.99"), Quantity: 3, Discount: 0.1},
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`0.1 + 0.2`, "0.3"},
+		{`0.1 + 0.2 == 0.3`, "true"},
+		{`1 / 3`, "0.3333333333333333"},
+		{`10 / 4 * 2`, "5"},
+		{`-0.5 * 3`, "-1.5"},
+		{`Order.Price * Order.Quantity`, "59.97"},
+		{`Order.Price * Order.Quantity * (1 - Order.Discount)`, "53.973"},
+		{`Order.Price > 19.98 && Order.Price < 20`, "true"},
+		{`Order.Price * 100 == 1999`, "true"},
+		{"`total: ${Order.Price * Order.Quantity}`", "total: 59.97"},
+		{`2 ** 0.5 > 1.41`, "true"},
+		{`7 div 2 + 7 % 2`, "4"},
+		{`type(float("0.5")) + type(0.5) + type(Order.Price)`, "floatdecimaldecimal"},
+		{`int(Order.Price) + float(0.5)`, "19.5"},
+		{`[1h / 4, 1h * 1.5, 90m / 1h]`, "[15m0s 1h30m0s 1.5]"},
+		{`[0.1, 0.2][0] + 0.2`, "0.3"},
+		{`[sum([0.1, 0.2]), sum([Order.Price, 0.01])]`, "[0.3 20]"},
+		{`type(math.max(0.1, 0.3)) + type(math.abs(-0.5))`, "decimaldecimal"},
+		{`[math.round(Order.Price), math.round(Order.Price, 1), math.abs(-Order.Price)]`, "[20 20 19.99]"},
+		{`[math.floor(Order.Price), math.ceil(Order.Price), math.trunc(-Order.Price)]`, "[19 20 -19]"},
+		{`[math.min(Order.Price, 20), math.max(Order.Price, 20), math.clamp(Order.Price, 0, 10)]`, "[19.99 20 10]"},
+		{`[Order.Price % 2, Order.Price ** 2, math.pow(Order.Price, 2)]`, "[1.99 399.6001 399.6001]"},
+		{`type(Order.Price ** 2) + type(Order.Price % 2)`, "decimaldecimal"},
+	}
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.code, expr.Env(env), expr.DecimalNumbers())
+		require.NoError(t, err, tt.code)
+
+		got, err := expr.Run(program, env)
+		require.NoError(t, err, tt.code)
+		assert.Equal(t, tt.want, fmt.Sprint(got), tt.code)
+	}
+
+	// Without the option only the decimal fields are decimals.
+	got, err := expr.Eval(`[0.1 + 0.2, Order.Price + 0.01, Order.Price / 2]`, env)
+	require.NoError(t, err)
+	assert.Equal(t, "[0.30000000000000004 20 9.995]", fmt.Sprint(got))
+
+	_, err = expr.Compile(`Order.Price + "1"`, expr.Env(env), expr.DecimalNumbers())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid operation: + (mismatched types decimal.Decimal and string)")
+}
+
+type cents int64
+
+func TestExpr_registered_decimal(t *testing.T) {
+	decimal.Register(cents(0), func(v interface{}) decimal.Decimal {
+		return decimal.New(int64(v.(cents)), 2)
+	})
+	env := map[string]interface{}{"Price": cents(1999)}
+
+	got, err := expr.Eval(`[Price * 3, math.round(Price), Price % 2, type(Price)]`, env)
+	require.NoError(t, err)
+	assert.Equal(t, "[59.97 20 1.99 decimal]", fmt.Sprint(got))
+}
+
+func TestExpr_comments(t *testing.T) {
+	code := `
+		// Cheap tickets on the direct flight.
+		Ticket.Price < 200 && /* a single segment */ len(Segments) == 2
+	`
+	got, err := expr.Eval(code, &mockEnv{Ticket: &ticket{Price: 100}, Segments: []*segment{{}, {}}})
+	require.NoError(t, err)
+	assert.Equal(t, true, got)
+
+	code = "/* price\n   check */ Ticket.Price >\n// typo\nTicket.Prise"
+	_, err = expr.Compile(code, expr.Env(&mockEnv{}))
+	require.Error(t, err)
+	assert.Equal(t, "type expr_test.ticket has no field Prise (4:8)\n | Ticket.Prise\n | .......^", err.Error())
+}
+
+func TestExpr_index_out_of_range(t *testing.T) {
+	env := &mockEnv{Array: []int{1, 2, 3}}
+
+	_, err := expr.Eval(`Array[Int64 - 4]`, env)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index out of range: -4 (array length is 3)")
+
+	_, err = expr.Eval(`Array[::Int64]`, env)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "slice step cannot be zero")
+
+	_, err = expr.Compile(`[1, 2, 3][-4]`)
+	require.Error(t, err)
+	assert.Equal(t, "index out of range: -4 (array length is 3) (1:11)\n | [1, 2, 3][-4]\n | ..........^", err.Error())
+
+	_, err = expr.Eval(`String[5]`, &mockEnv{String: "héllo"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index out of range: 5 (string length is 5)")
+}
+
+func TestExpr_conversion_error(t *testing.T) {
+	_, err := expr.Eval(`1 + int(String)`, &mockEnv{String: "1.5"})
+	require.Error(t, err)
+	assert.Equal(t, "cannot convert \"1.5\" to int (1:5)\n | 1 + int(String)\n | ....^", err.Error())
+
+	_, err = expr.Compile(`float("abc")`)
+	require.Error(t, err)
+	assert.Equal(t, "cannot convert \"abc\" to float (1:1)\n | float(\"abc\")\n | ^", err.Error())
+
+	// The runtime reports nil like the checker, string converts it as
+	// the template strings do.
+	env := map[string]interface{}{"Value": nil}
+	_, err = expr.Eval(`float(Value)`, env)
+	require.Error(t, err)
+	assert.Equal(t, "cannot convert nil to float (1:1)\n | float(Value)\n | ^", err.Error())
+
+	got, err := expr.Eval("string(Value) + `${Value}`", env)
+	require.NoError(t, err)
+	assert.Equal(t, "<nil><nil>", got)
+}
+
+func TestExpr_reduce_empty(t *testing.T) {
+	_, err := expr.Eval(`reduce([], {#acc + #})`, nil)
+	require.Error(t, err)
+	assert.Equal(t, "reduce of empty array with no initial value (1:1)\n | reduce([], {#acc + #})\n | ^", err.Error())
+
+	got, err := expr.Eval(`reduce([], {#acc + #}, 0)`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got)
+}
+
+func TestExpr_empty_array(t *testing.T) {
+	env := map[string]interface{}{"Empty": []int{}}
+	for _, name := range []string{"min", "max", "avg", "first", "last"} {
+		code := name + "(Empty)"
+		_, err := expr.Eval(code, env)
+		require.Error(t, err, code)
+		assert.Equal(t, name+" of empty array (1:1)\n | "+code+"\n | ^", err.Error())
+	}
+}
+
 func TestExpr_optional_chaining(t *testing.T) {
 	env := map[string]interface{}{}
 	program, err := expr.Compile("foo?.bar.baz", expr.Env(env), expr.AllowUndefinedVariables())
@@ -1071,6 +1667,17 @@ func TestExpr_optional_chaining_nested_chains(t *testing.T) {
 	assert.Equal(t, "baz", got)
 }
 
+func TestExpr_optional_chaining_nil_func(t *testing.T) {
+	var nilf func(...int) int
+	env := map[string]interface{}{"Nilf": nilf}
+	program, err := expr.Compile("[Nilf?.(1), Nilf?.(1) == nil, Nilf?.(1) ?? 1]", expr.Env(env))
+	require.NoError(t, err)
+
+	got, err := expr.Run(program, env)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{nil, true, 1}, got)
+}
+
 func TestExpr_eval_with_env(t *testing.T) {
 	_, err := expr.Eval("true", expr.Env(map[string]interface{}{}))
 	assert.Error(t, err)
@@ -1253,6 +1860,116 @@ func (p *stringerPatcher) Visit(node *ast.Node) {
 	}
 }
 
+func TestFormat_error(t *testing.T) {
+	_, err := expr.Format(`a +`)
+	require.Error(t, err)
+	require.Equal(t, "unexpected token EOF (1:3)\n | a +\n | ..^", err.Error())
+}
+
+func TestCompileTree(t *testing.T) {
+	env := &mockEnv{
+		Array:  []int{1, 2, 3, 4, 5},
+		String: "string",
+		Ticket: &ticket{Price: 100},
+	}
+	tests := []string{
+		`Array[1:-1] == [2, 3, 4] and String[::-1] == "gnirts"`,
+		`map(filter(Array, {# > 2}), x => x * 2)`,
+		`reduce(Array, {#acc + #}, 0) + Ticket.Price`,
+		`let x = Ticket?.Price; x > 50 ? x : -x`,
+		"`price: ${Ticket.Price}, ${String |> strings.upper()}`",
+		`switch Ticket.Price { case 1, 2 => "a"; case 3..100 => "b"; default => "c" }`,
+		`{a: 1, ...{b: 2}}.b + len([...Array, 6]) + math.abs(-1)`,
+		`1h30m == 90m and String matches "^s"`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expected, err := expr.Compile(input, expr.Env(&mockEnv{}))
+			require.NoError(t, err)
+
+			parsed, err := parser.Parse(input)
+			require.NoError(t, err)
+			b, err := ast.Marshal(parsed.Node)
+			require.NoError(t, err)
+			node, err := ast.Unmarshal(b)
+			require.NoError(t, err)
+
+			program, err := expr.CompileTree(parser.NewTree(node), expr.Env(&mockEnv{}))
+			require.NoError(t, err)
+			assert.Equal(t, expected.Disassemble(), program.Disassemble())
+			assert.Equal(t, expected.Locations, program.Locations)
+
+			want, err := expr.Run(expected, env)
+			require.NoError(t, err)
+			got, err := expr.Run(program, env)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestCompileTree_error(t *testing.T) {
+	foo := &ast.IdentifierNode{Value: "Foo"}
+	foo.SetLocation(file.Location{Line: 1, Column: 4})
+	node := &ast.BinaryNode{
+		Operator: "+",
+		Left:     foo,
+		Right:    &ast.IntegerNode{Value: 1},
+	}
+
+	_, err := expr.CompileTree(parser.NewTree(node), expr.Env(&mockEnv{}))
+	require.Error(t, err)
+	require.Equal(t, "unknown name Foo (1:5)", err.Error()) // No source, no snippet.
+}
+
+func TestCompileTree_builtin_arguments(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string
+		code file.ErrorCode
+	}{
+		{`{"Kind":"Builtin","Name":"len","Arguments":[]}`, "not enough arguments to call len", file.ArgumentCount},
+		{`{"Kind":"Builtin","Namespace":"math","Name":"abs"}`, "not enough arguments to call math.abs", file.ArgumentCount},
+		{`{"Kind":"Builtin","Name":"len","Arguments":[{"Kind":"Nil"},{"Kind":"Nil"}]}`, "too many arguments to call len", file.ArgumentCount},
+		{`{"Kind":"Builtin","Name":"map","Arguments":[{"Kind":"Array","Nodes":[]},{"Kind":"Nil"}]}`, "argument 2 of map must be a closure", file.TypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			node, err := ast.Unmarshal([]byte(tt.json))
+			require.NoError(t, err)
+
+			_, err = expr.CompileTree(parser.NewTree(node))
+			require.Error(t, err)
+			fileError, ok := err.(*file.Error)
+			require.True(t, ok, "error should be of type *file.Error")
+			assert.Equal(t, tt.err, fileError.Message)
+			assert.Equal(t, tt.code, fileError.Code)
+		})
+	}
+}
+
+func TestCompileTree_optional_without_chain(t *testing.T) {
+	// Trees built without the parser may not wrap optional nodes in a chain.
+	tests := []string{
+		`{"Kind":"Member","Node":{"Kind":"Identifier","Value":"Foo"},"Property":{"Kind":"String","Value":"Bar"},"Optional":true}`,
+		`{"Kind":"Call","Callee":{"Kind":"Identifier","Value":"Foo"},"Arguments":[],"Optional":true}`,
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			node, err := ast.Unmarshal([]byte(input))
+			require.NoError(t, err)
+
+			program, err := expr.CompileTree(parser.NewTree(node))
+			require.NoError(t, err)
+
+			got, err := expr.Run(program, map[string]interface{}{"Foo": nil})
+			require.NoError(t, err)
+			assert.Nil(t, got)
+		})
+	}
+}
+
 func TestPatch(t *testing.T) {
 	program, err := expr.Compile(
 		`Ticket == "$100" and "$90" != Ticket + "0"`,
@@ -1297,6 +2014,90 @@ func TestPatch_length(t *testing.T) {
 	require.Equal(t, true, output)
 }
 
+func TestNamespace(t *testing.T) {
+	env := map[string]interface{}{
+		"a": 3,
+	}
+
+	program, err := expr.Compile(`geo.distance(a, 10) + math.abs(-1)`, expr.Env(env), expr.Namespace(mock.NewGeo()))
+	require.NoError(t, err)
+
+	output, err := expr.Run(program, env)
+	require.NoError(t, err)
+	assert.Equal(t, float64(8), output)
+
+	program, err = expr.Compile(`geo.distance(b, 10)`, expr.Env(env), expr.AllowUndefinedVariables(), expr.Namespace(mock.NewGeo()))
+	require.NoError(t, err)
+
+	_, err = expr.Run(program, env)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "geo.distance expects numbers (got nil)")
+
+	_, err = expr.Compile(`geo.distance(a, 10)`, expr.Env(env))
+	require.Error(t, err)
+}
+
+func TestClock(t *testing.T) {
+	clock := func() time.Time {
+		return time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	program, err := expr.Compile(`time.format(time.now(), "2006-01-02 15:04")`, expr.Clock(clock))
+	require.NoError(t, err)
+
+	output, err := expr.Run(program, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2021-05-01 12:00", output)
+
+	program, err = expr.Compile(`time.now()`)
+	require.NoError(t, err)
+
+	output, err = expr.Run(program, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, clock(), output)
+}
+
+func TestNamespace_redefine(t *testing.T) {
+	assert.Panics(t, func() {
+		_, _ = expr.Compile(`1`, expr.Namespace(lib_math.NewBuiltinMath()))
+	})
+	assert.Panics(t, func() {
+		_, _ = expr.Compile(`1`, expr.Clock(time.Now), expr.Clock(time.Now))
+	})
+}
+
+func TestNamespace_shadowed_by_env(t *testing.T) {
+	env := map[string]interface{}{
+		"time":    5,
+		"strings": []string{"a", "b"},
+	}
+
+	program, err := expr.Compile(`time + len(strings) + math.abs(-1)`, expr.Env(env))
+	require.NoError(t, err)
+
+	output, err := expr.Run(program, env)
+	require.NoError(t, err)
+	assert.Equal(t, 8, output)
+
+	program, err = expr.Compile(`time.unix(time.now())`, expr.Env(env), expr.Clock(func() time.Time {
+		return time.Unix(42, 0)
+	}))
+	require.NoError(t, err)
+
+	output, err = expr.Run(program, env)
+	require.NoError(t, err)
+	assert.Equal(t, 42, output)
+
+	// Without Env only a name followed by a member is a namespace.
+	output, err = expr.Eval(`time + 1`, env)
+	require.NoError(t, err)
+	assert.Equal(t, 6, output)
+
+	output, err = expr.Eval(`len(strings) + len(strings.upper("ab"))`, env)
+	require.NoError(t, err)
+	assert.Equal(t, 4, output)
+}
+
 func TestCompile_exposed_error(t *testing.T) {
 	_, err := expr.Compile(`1 == true`)
 	require.Error(t, err)
@@ -1309,7 +2110,142 @@ func TestCompile_exposed_error(t *testing.T) {
 
 	b, err := json.Marshal(err)
 	require.NoError(t, err)
-	require.Equal(t, `{"Line":1,"Column":2,"Message":"invalid operation: == (mismatched types int and bool)","Snippet":"\n | 1 == true\n | ..^"}`, string(b))
+	require.Equal(t, `{"Line":1,"Column":2,"Offset":2,"EndLine":1,"EndColumn":4,"Code":"TypeMismatch","Message":"invalid operation: == (mismatched types int and bool)","Snippet":"\n | 1 == true\n | ..^"}`, string(b))
+}
+
+func TestCompile_multiple_errors(t *testing.T) {
+	env := map[string]interface{}{"Name": "", "Age": 0}
+
+	_, err := expr.Compile("Name + 1 > 0 && Age matches \"^1\"\n|| Age in Name", expr.Env(env))
+	require.Error(t, err)
+
+	errs := file.AsErrors(err)
+	require.Len(t, errs, 3)
+	assert.Equal(t, file.Location{Line: 1, Column: 5, Offset: 5, EndLine: 1, EndColumn: 6}, errs[0].Location)
+	assert.Equal(t, file.Location{Line: 1, Column: 20, Offset: 20, EndLine: 1, EndColumn: 27}, errs[1].Location)
+	assert.Equal(t, file.Location{Line: 2, Column: 7, Offset: 40, EndLine: 2, EndColumn: 9}, errs[2].Location)
+	assert.Equal(t, "invalid operation: in (mismatched types int and string) (2:8)\n | || Age in Name\n | .......^", errs[2].Error())
+	assert.Equal(t, errs[0].Error()+"\n"+errs[1].Error()+"\n"+errs[2].Error(), err.Error())
+
+	_, err = expr.Compile(`[1 +, 2, foo(, 3)]`)
+	require.Error(t, err)
+	require.Len(t, file.AsErrors(err), 2)
+
+	// Every mismatched argument of a call is reported.
+	env["Fn"] = func(xs ...int) int { return len(xs) }
+	_, err = expr.Compile(`Fn(1, Name, Name)`, expr.Env(env))
+	require.Error(t, err)
+	errs = file.AsErrors(err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 6, errs[0].Column)
+	assert.Equal(t, 12, errs[1].Column)
+
+	// Outside of lists, the parser stops at the first syntax error.
+	_, err = expr.Compile(`1 + * 2 + * 3`)
+	require.Len(t, file.AsErrors(err), 1)
+
+	// A single error is still *file.Error.
+	_, err = expr.Compile(`Name + 1`, expr.Env(env))
+	require.IsType(t, &file.Error{}, err)
+	require.Len(t, file.AsErrors(err), 1)
+}
+
+func TestError_code(t *testing.T) {
+	env := map[string]interface{}{
+		"Name":  "",
+		"Zero":  0,
+		"Array": []int{1, 2, 3},
+		"Fn":    func(a int) int { return a },
+		"Neg":   -1,
+		"Price": decimal.MustParse("1.5"),
+		"Big":   uint64(1) << 63,
+		"Fail":  func(msg string) int { panic(msg) },
+		"Coded": func() (int, error) { return 0, runtime.Errorf(file.InvalidArgument, "bad") },
+	}
+	tests := []struct {
+		input string
+		code  file.ErrorCode
+	}{
+		{`Name +`, file.SyntaxError},
+		{`Foo + 1`, file.UnknownIdentifier},
+		{`Name + 1`, file.TypeMismatch},
+		{`Name()`, file.NotCallable},
+		{`Fn(1, 2)`, file.ArgumentCount},
+		{`Array[5]`, file.IndexOutOfRange},
+		{`Array[::0]`, file.InvalidArgument},
+		{`1 % 0`, file.DivisionByZero},
+		{`1 % Zero`, file.DivisionByZero},
+		{`Array[Zero + 5]`, file.IndexOutOfRange},
+		{`int(Name)`, file.InvalidArgument},
+		{`map(1..100, {map(1..100, {map(1..100, {0})})})`, file.MemoryBudgetExceeded},
+		{`strings.repeat("ab", 100000000)`, file.MemoryBudgetExceeded},
+		{`strings.padLeft(Name, 100000000)`, file.MemoryBudgetExceeded},
+		{`strings.padRight("a", 100000000, "xy")`, file.MemoryBudgetExceeded},
+		{`1 div Zero`, file.DivisionByZero},
+		{`1 << Neg`, file.InvalidArgument},
+		{`Price / Zero`, file.DivisionByZero},
+		{`Price % Zero`, file.DivisionByZero},
+		{`Name.Foo`, file.TypeMismatch},
+		{`float(Name)`, file.InvalidArgument},
+		{`int(Big)`, file.InvalidArgument},
+		{`int(Price * 1e20)`, file.InvalidArgument},
+		// Codes are not guessed from messages of panics.
+		{`Fail("index out of range")`, file.RuntimeError},
+		{`Coded()`, file.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := expr.Compile(tt.input, expr.Env(env))
+			if err == nil {
+				_, err = expr.Run(program, env)
+			}
+			require.Error(t, err)
+			fileError, ok := err.(*file.Error)
+			require.True(t, ok, "error should be of type *file.Error")
+			assert.Equal(t, tt.code, fileError.Code, fileError.Error())
+		})
+	}
+}
+
+func TestExpr_int_out_of_range(t *testing.T) {
+	// Constants are folded, so the error is reported by the compiler.
+	_, err := expr.Compile(`int(1e30)`)
+	require.Error(t, err)
+	assert.Equal(t, "cannot convert 1e+30 to int (out of range) (1:1)\n | int(1e30)\n | ^", err.Error())
+
+	env := map[string]interface{}{"U": uint64(1) << 63, "F": math.NaN()}
+	for _, code := range []string{`int(U)`, `int(F)`} {
+		_, err = expr.Eval(code, env)
+		require.Error(t, err, code)
+		fileError, ok := err.(*file.Error)
+		require.True(t, ok, "error should be of type *file.Error")
+		assert.Equal(t, file.InvalidArgument, fileError.Code)
+	}
+	_, err = expr.Eval(`int(U)`, env)
+	assert.Equal(t, "cannot convert 9223372036854775808 to int (out of range) (1:1)\n | int(U)\n | ^", err.Error())
+
+	got, err := expr.Eval(`int(U - 1)`, env)
+	require.NoError(t, err)
+	assert.Equal(t, math.MaxInt64, got)
+}
+
+func TestStrings_memory_budget(t *testing.T) {
+	// Constant arguments are not folded, the string is never allocated.
+	program, err := expr.Compile(`strings.repeat("ab", 100000000)`)
+	require.NoError(t, err)
+	_, err = expr.Run(program, nil)
+	require.Error(t, err)
+	assert.Equal(t, "memory budget exceeded (1:1)\n | strings.repeat(\"ab\", 100000000)\n | ^", err.Error())
+}
+
+func TestError_location(t *testing.T) {
+	_, err := expr.Compile("1 +\n  unknown", expr.Env(map[string]interface{}{}))
+	require.Error(t, err)
+
+	fileError, ok := err.(*file.Error)
+	require.True(t, ok, "error should be of type *file.Error")
+	assert.Equal(t, file.UnknownIdentifier, fileError.Code)
+	assert.Equal(t, file.Location{Line: 2, Column: 2, Offset: 6, EndLine: 2, EndColumn: 9}, fileError.Location)
 }
 
 func TestCompile_deref(t *testing.T) {
@@ -8,10 +8,69 @@ import (
 
 type Error struct {
 	Location
+	Code    ErrorCode
 	Message string
 	Snippet string
 }
 
+// ErrorCode is the kind of the error, which, unlike the message,
+// is stable and can be relied upon by tools.
+type ErrorCode int
+
+// The values of the codes never change, new codes are only appended.
+const (
+	UnknownError         ErrorCode = iota
+	SyntaxError                    // The source cannot be parsed.
+	UnknownIdentifier              // A variable, field, method or builtin does not exist.
+	AmbiguousIdentifier            // A name refers to more than one embedded field or method.
+	TypeMismatch                   // A value of a type is used where it is not allowed.
+	NotCallable                    // A value is called, but it is not a function.
+	ArgumentCount                  // A function is called with a wrong number of arguments.
+	InvalidArgument                // A value is of the right type, but not allowed, like a zero slice step.
+	IndexOutOfRange                // An array or a string is indexed out of its bounds.
+	DivisionByZero                 // An integer is divided by zero.
+	MemoryBudgetExceeded           // An expression allocates more than its memory budget.
+	RuntimeError                   // Any other error raised while running an expression.
+)
+
+var errorCodes = [...]string{
+	UnknownError:         "UnknownError",
+	SyntaxError:          "SyntaxError",
+	UnknownIdentifier:    "UnknownIdentifier",
+	AmbiguousIdentifier:  "AmbiguousIdentifier",
+	TypeMismatch:         "TypeMismatch",
+	NotCallable:          "NotCallable",
+	ArgumentCount:        "ArgumentCount",
+	InvalidArgument:      "InvalidArgument",
+	IndexOutOfRange:      "IndexOutOfRange",
+	DivisionByZero:       "DivisionByZero",
+	MemoryBudgetExceeded: "MemoryBudgetExceeded",
+	RuntimeError:         "RuntimeError",
+}
+
+func (c ErrorCode) String() string {
+	if c >= 0 && int(c) < len(errorCodes) {
+		return errorCodes[c]
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+// MarshalText encodes the code by its name, so JSON errors are readable.
+func (c ErrorCode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes the code from its name.
+func (c *ErrorCode) UnmarshalText(text []byte) error {
+	for code, name := range errorCodes {
+		if name == string(text) {
+			*c = ErrorCode(code)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error code %q", text)
+}
+
 func (e *Error) Error() string {
 	return e.format()
 }
@@ -56,3 +115,46 @@ func (e *Error) format() string {
 		e.Snippet,
 	)
 }
+
+// Errors is a list of errors found in one pass, in the order of appearance.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Bind binds all errors to the source.
+func (e Errors) Bind(source *Source) Errors {
+	for _, err := range e {
+		err.Bind(source)
+	}
+	return e
+}
+
+// Err returns nil for no errors, the error itself for a single one,
+// and the list for many.
+func (e Errors) Err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
+// AsErrors returns the list of errors of err, which is either *Error or
+// Errors. Other errors are not positioned and result in nil.
+func AsErrors(err error) Errors {
+	switch e := err.(type) {
+	case *Error:
+		return Errors{e}
+	case Errors:
+		return e
+	}
+	return nil
+}
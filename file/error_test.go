@@ -0,0 +1,25 @@
+package file
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCode_json(t *testing.T) {
+	for code := UnknownError; code <= RuntimeError; code++ {
+		b, err := json.Marshal(code)
+		require.NoError(t, err)
+
+		var decoded ErrorCode
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		assert.Equal(t, code, decoded)
+	}
+	assert.Equal(t, "DivisionByZero", DivisionByZero.String())
+	assert.Equal(t, "ErrorCode(100)", ErrorCode(100).String())
+
+	var decoded ErrorCode
+	assert.Error(t, json.Unmarshal([]byte(`"Unknown"`), &decoded))
+}
@@ -1,8 +1,11 @@
 package file
 
 type Location struct {
-	Line   int // The 1-based line of the location.
-	Column int // The 0-based column number of the location.
+	Line      int // The 1-based line of the location.
+	Column    int // The 0-based column number of the location.
+	Offset    int // The 0-based byte offset of the location in the source.
+	EndLine   int // The 1-based line of the end of the location's token.
+	EndColumn int // The 0-based column just after the end of the location's token.
 }
 
 func (l Location) Empty() bool {
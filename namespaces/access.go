@@ -8,6 +8,5 @@ import (
 var Stdlib builtin.BuiltinNamespace = lib_std.NewBuiltinStandard()
 
 func Get(name string) (builtin.BuiltinNamespace, bool) {
-	b, ok := mapped[name]
-	return b, ok
+	return mapped.Get(name)
 }
@@ -4,30 +4,206 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
+	"github.com/antonmedv/expr/file"
 	"github.com/antonmedv/expr/util/checking"
 	. "github.com/antonmedv/expr/util/typing"
 )
 
 func (f *F_abs) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
-	if len(node.Arguments) != 1 {
-		return v.Error(node, "math.abs expects one number as input")
+	return visit_preserving(v, node)
+}
+
+func (f *F_floor) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_preserving(v, node)
+}
+
+func (f *F_ceil) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_preserving(v, node)
+}
+
+func (f *F_trunc) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_preserving(v, node)
+}
+
+func (f *F_round) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	if len(node.Arguments) == 2 {
+		digits, _ := v.Visit(node.Arguments[1])
+		if !IsInteger(digits) && !IsAny(digits) {
+			return v.Error(node.Arguments[1], "%s.%s expects an integer as digits (got %v)", node.Namespace, node.Name, digits)
+		}
+	}
+	return visit_preserving(v, node)
+}
+
+func (f *F_sqrt) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_pow) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	types, ok := visit_numbers(v, node.Arguments)
+	if !ok {
+		return v.Error(node, "%s.%s expects numbers as input (got %v)", node.Namespace, node.Name, types)
+	}
+	if IsDecimal(types[0]) || IsDecimal(types[1]) {
+		return DecimalType, checking.Info{}
+	}
+	return FloatType, checking.Info{}
+}
+
+func (f *F_log) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_log10) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_exp) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_sin) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_cos) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_tan) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_asin) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_acos) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_atan) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_atan2) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_float(v, node)
+}
+
+func (f *F_min) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_combined(v, node)
+}
+
+func (f *F_max) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_combined(v, node)
+}
+
+func (f *F_clamp) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_combined(v, node)
+}
+
+func (f *F_sign) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	if _, ok := visit_numbers(v, node.Arguments); !ok {
+		return v.Error(node, "%s.%s expects numbers as input", node.Namespace, node.Name)
 	}
+	return IntegerType, checking.Info{}
+}
+
+func (c *C_pi) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
 
+func (c *C_e) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
+
+func (c *C_inf) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
+
+func (c *C_nan) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
+
+func (c *C_maxInt) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
+
+func (c *C_minInt) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_constant(c, v, node)
+}
+
+// visit_numbers visits all nodes and reports whether all of them are numbers.
+func visit_numbers(v *checking.ExternVisitor, nodes []ast.Node) ([]reflect.Type, bool) {
+	types := make([]reflect.Type, len(nodes))
+	ok := true
+	for i, arg := range nodes {
+		types[i], _ = v.Visit(arg)
+		if !IsNumber(types[i]) && !IsAny(types[i]) {
+			ok = false
+		}
+	}
+	return types, ok
+}
+
+// visit_preserving checks functions which return a number of the same kind,
+// as they got in the first argument.
+func visit_preserving(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	param, _ := v.Visit(node.Arguments[0])
 
-	if IsFloat(param) {
+	if IsDecimal(param) {
+		return DecimalType, checking.Info{}
+	} else if IsFloat(param) {
 		return FloatType, checking.Info{}
 	} else if IsInteger(param) {
 		return IntegerType, checking.Info{}
+	} else if IsAny(param) {
+		return AnyType, checking.Info{}
+	}
+
+	return v.Error(node, "%s.%s expects a number as input (got %v)", node.Namespace, node.Name, param)
+}
+
+func visit_float(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	types, ok := visit_numbers(v, node.Arguments)
+	if !ok {
+		return v.Error(node, "%s.%s expects numbers as input (got %v)", node.Namespace, node.Name, types)
+	}
+	return FloatType, checking.Info{}
+}
+
+// visit_combined checks functions returning one of their arguments.
+// Result is an integer only if all arguments are integers.
+func visit_combined(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	types, ok := visit_numbers(v, node.Arguments)
+	if !ok {
+		return v.Error(node, "%s.%s expects numbers as input (got %v)", node.Namespace, node.Name, types)
 	}
 
-	return v.Error(node, "math.abs expects a number as input - got: %v", param)
+	t := types[0]
+	for _, next := range types[1:] {
+		if IsAny(t) || IsAny(next) {
+			return AnyType, checking.Info{}
+		}
+		t = ToNumbertype(t, next)
+	}
+	if IsAny(t) {
+		return AnyType, checking.Info{}
+	}
+	if IsDecimal(t) {
+		return DecimalType, checking.Info{}
+	}
+	if IsFloat(t) {
+		return FloatType, checking.Info{}
+	}
+	return IntegerType, checking.Info{}
 }
 
-func (f *C_pi) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+func visit_constant(c builtin.Constant, v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	if node.Arguments != nil {
-		return v.Error(node, "math.pi is a constant - it cannot be invoked")
+		return v.ErrorCode(node, file.NotCallable, "%s.%s is a constant - it cannot be invoked", node.Namespace, node.Name)
 	}
 
-	return FloatType, checking.Info{}
+	return reflect.TypeOf(c.GetValue()), checking.Info{}
 }
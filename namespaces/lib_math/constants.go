@@ -0,0 +1,79 @@
+package lib_math
+
+import (
+	"math"
+
+	"github.com/antonmedv/expr/builtin"
+)
+
+type C_pi struct {
+	builtin.BaseConstant
+}
+
+func (c *C_pi) Name() string {
+	return "pi"
+}
+
+func (c *C_pi) GetValue() interface{} {
+	return math.Pi
+}
+
+type C_e struct {
+	builtin.BaseConstant
+}
+
+func (c *C_e) Name() string {
+	return "e"
+}
+
+func (c *C_e) GetValue() interface{} {
+	return math.E
+}
+
+type C_inf struct {
+	builtin.BaseConstant
+}
+
+func (c *C_inf) Name() string {
+	return "inf"
+}
+
+func (c *C_inf) GetValue() interface{} {
+	return math.Inf(1)
+}
+
+type C_nan struct {
+	builtin.BaseConstant
+}
+
+func (c *C_nan) Name() string {
+	return "nan"
+}
+
+func (c *C_nan) GetValue() interface{} {
+	return math.NaN()
+}
+
+type C_maxInt struct {
+	builtin.BaseConstant
+}
+
+func (c *C_maxInt) Name() string {
+	return "maxInt"
+}
+
+func (c *C_maxInt) GetValue() interface{} {
+	return math.MaxInt64
+}
+
+type C_minInt struct {
+	builtin.BaseConstant
+}
+
+func (c *C_minInt) Name() string {
+	return "minInt"
+}
+
+func (c *C_minInt) GetValue() interface{} {
+	return math.MinInt64
+}
@@ -2,24 +2,227 @@ package lib_math
 
 import "github.com/antonmedv/expr/builtin"
 
+var arg_one_expression []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+}
+
+var arg_two_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_three_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_expression_and_optional []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression, Optional: true},
+}
+
+var arg_variadic_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression, Variadic: true},
+}
+
+type OneExpressionAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *OneExpressionAccepting) Arguments() []builtin.Argument {
+	return arg_one_expression
+}
+
+type TwoExpressionsAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *TwoExpressionsAccepting) Arguments() []builtin.Argument {
+	return arg_two_expressions
+}
+
 type F_abs struct {
-	builtin.BaseFunc
+	OneExpressionAccepting
 }
 
 func (f *F_abs) Name() string {
 	return "abs"
 }
 
-func (f *F_abs) Arguments() []builtin.Argument {
-	return []builtin.Argument{
-		{ParserType: builtin.Expression},
-	}
+type F_floor struct {
+	OneExpressionAccepting
+}
+
+func (f *F_floor) Name() string {
+	return "floor"
+}
+
+type F_ceil struct {
+	OneExpressionAccepting
+}
+
+func (f *F_ceil) Name() string {
+	return "ceil"
+}
+
+type F_trunc struct {
+	OneExpressionAccepting
+}
+
+func (f *F_trunc) Name() string {
+	return "trunc"
+}
+
+type F_round struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_round) Name() string {
+	return "round"
+}
+
+func (f *F_round) Arguments() []builtin.Argument {
+	return arg_expression_and_optional
+}
+
+type F_sqrt struct {
+	OneExpressionAccepting
+}
+
+func (f *F_sqrt) Name() string {
+	return "sqrt"
+}
+
+type F_pow struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_pow) Name() string {
+	return "pow"
+}
+
+type F_log struct {
+	OneExpressionAccepting
+}
+
+func (f *F_log) Name() string {
+	return "log"
+}
+
+type F_log10 struct {
+	OneExpressionAccepting
+}
+
+func (f *F_log10) Name() string {
+	return "log10"
+}
+
+type F_exp struct {
+	OneExpressionAccepting
+}
+
+func (f *F_exp) Name() string {
+	return "exp"
+}
+
+type F_sin struct {
+	OneExpressionAccepting
+}
+
+func (f *F_sin) Name() string {
+	return "sin"
+}
+
+type F_cos struct {
+	OneExpressionAccepting
+}
+
+func (f *F_cos) Name() string {
+	return "cos"
+}
+
+type F_tan struct {
+	OneExpressionAccepting
+}
+
+func (f *F_tan) Name() string {
+	return "tan"
+}
+
+type F_asin struct {
+	OneExpressionAccepting
+}
+
+func (f *F_asin) Name() string {
+	return "asin"
+}
+
+type F_acos struct {
+	OneExpressionAccepting
+}
+
+func (f *F_acos) Name() string {
+	return "acos"
+}
+
+type F_atan struct {
+	OneExpressionAccepting
+}
+
+func (f *F_atan) Name() string {
+	return "atan"
+}
+
+type F_atan2 struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_atan2) Name() string {
+	return "atan2"
+}
+
+type F_min struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_min) Name() string {
+	return "min"
+}
+
+func (f *F_min) Arguments() []builtin.Argument {
+	return arg_variadic_expressions
+}
+
+type F_max struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_max) Name() string {
+	return "max"
+}
+
+func (f *F_max) Arguments() []builtin.Argument {
+	return arg_variadic_expressions
+}
+
+type F_clamp struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_clamp) Name() string {
+	return "clamp"
+}
+
+func (f *F_clamp) Arguments() []builtin.Argument {
+	return arg_three_expressions
 }
 
-type C_pi struct {
-	builtin.BaseFunc
+type F_sign struct {
+	OneExpressionAccepting
 }
 
-func (f *C_pi) Name() string {
-	return "pi"
+func (f *F_sign) Name() string {
+	return "sign"
 }
@@ -12,7 +12,32 @@ func NewBuiltinMath() builtin.BuiltinNamespace {
 	return &BuiltinMath{
 		builtin.ContainerWith(
 			&F_abs{},
+			&F_floor{},
+			&F_ceil{},
+			&F_round{},
+			&F_trunc{},
+			&F_sqrt{},
+			&F_pow{},
+			&F_log{},
+			&F_log10{},
+			&F_exp{},
+			&F_sin{},
+			&F_cos{},
+			&F_tan{},
+			&F_asin{},
+			&F_acos{},
+			&F_atan{},
+			&F_atan2{},
+			&F_min{},
+			&F_max{},
+			&F_clamp{},
+			&F_sign{},
 			&C_pi{},
+			&C_e{},
+			&C_inf{},
+			&C_nan{},
+			&C_maxInt{},
+			&C_minInt{},
 		),
 	}
 }
@@ -0,0 +1,185 @@
+package lib_math
+
+import (
+	"math"
+
+	"github.com/antonmedv/expr/decimal"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+func (f *F_abs) Run(args ...interface{}) (interface{}, error) {
+	if d, ok := decimal.From(args[0]); ok {
+		if d.Sign() < 0 {
+			return d.Neg(), nil
+		}
+		return d, nil
+	}
+	if isFloat(args[0]) {
+		return math.Abs(runtime.ToFloat64(args[0])), nil
+	}
+	x := runtime.ToInt(args[0])
+	if x < 0 {
+		return -x, nil
+	}
+	return x, nil
+}
+
+func (f *F_floor) Run(args ...interface{}) (interface{}, error) {
+	return preserving(args[0], math.Floor, decimal.Decimal.Floor), nil
+}
+
+func (f *F_ceil) Run(args ...interface{}) (interface{}, error) {
+	return preserving(args[0], math.Ceil, decimal.Decimal.Ceil), nil
+}
+
+func (f *F_trunc) Run(args ...interface{}) (interface{}, error) {
+	return preserving(args[0], math.Trunc, decimal.Decimal.Truncate), nil
+}
+
+func (f *F_round) Run(args ...interface{}) (interface{}, error) {
+	digits := 0
+	if len(args) == 2 {
+		digits = runtime.ToInt(args[1])
+	}
+	if d, ok := decimal.From(args[0]); ok {
+		return d.Round(int32(digits)), nil
+	}
+	if isFloat(args[0]) {
+		return round(runtime.ToFloat64(args[0]), digits), nil
+	}
+	// Integers are changed only by negative digits: round(1234, -2) == 1200.
+	return int(round(float64(runtime.ToInt(args[0])), digits)), nil
+}
+
+func (f *F_sqrt) Run(args ...interface{}) (interface{}, error) {
+	return math.Sqrt(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_pow) Run(args ...interface{}) (interface{}, error) {
+	return runtime.Exponent(args[0], args[1]), nil
+}
+
+func (f *F_log) Run(args ...interface{}) (interface{}, error) {
+	return math.Log(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_log10) Run(args ...interface{}) (interface{}, error) {
+	return math.Log10(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_exp) Run(args ...interface{}) (interface{}, error) {
+	return math.Exp(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_sin) Run(args ...interface{}) (interface{}, error) {
+	return math.Sin(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_cos) Run(args ...interface{}) (interface{}, error) {
+	return math.Cos(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_tan) Run(args ...interface{}) (interface{}, error) {
+	return math.Tan(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_asin) Run(args ...interface{}) (interface{}, error) {
+	return math.Asin(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_acos) Run(args ...interface{}) (interface{}, error) {
+	return math.Acos(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_atan) Run(args ...interface{}) (interface{}, error) {
+	return math.Atan(runtime.ToFloat64(args[0])), nil
+}
+
+func (f *F_atan2) Run(args ...interface{}) (interface{}, error) {
+	return math.Atan2(runtime.ToFloat64(args[0]), runtime.ToFloat64(args[1])), nil
+}
+
+func (f *F_min) Run(args ...interface{}) (interface{}, error) {
+	result := args[0]
+	for _, arg := range args[1:] {
+		if runtime.Less(arg, result) {
+			result = arg
+		}
+	}
+	return combined(result, args), nil
+}
+
+func (f *F_max) Run(args ...interface{}) (interface{}, error) {
+	result := args[0]
+	for _, arg := range args[1:] {
+		if runtime.More(arg, result) {
+			result = arg
+		}
+	}
+	return combined(result, args), nil
+}
+
+func (f *F_clamp) Run(args ...interface{}) (interface{}, error) {
+	result := args[0]
+	if runtime.Less(result, args[1]) {
+		result = args[1]
+	}
+	if runtime.More(result, args[2]) {
+		result = args[2]
+	}
+	return combined(result, args), nil
+}
+
+func (f *F_sign) Run(args ...interface{}) (interface{}, error) {
+	switch {
+	case runtime.Less(args[0], 0):
+		return -1, nil
+	case runtime.More(args[0], 0):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func isFloat(x interface{}) bool {
+	switch x.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+func round(x float64, digits int) float64 {
+	if digits < 0 {
+		p := math.Pow10(-digits)
+		return math.Round(x/p) * p
+	}
+	p := math.Pow10(digits)
+	return math.Round(x*p) / p
+}
+
+// preserving applies fn to floats, dfn to decimals and returns integers as is.
+func preserving(x interface{}, fn func(float64) float64, dfn func(decimal.Decimal) decimal.Decimal) interface{} {
+	if d, ok := decimal.From(x); ok {
+		return dfn(d)
+	}
+	if isFloat(x) {
+		return fn(runtime.ToFloat64(x))
+	}
+	return runtime.ToInt(x)
+}
+
+// combined converts the result to decimal, if any of the arguments is
+// decimal, or to float, if any of the arguments is float.
+func combined(result interface{}, args []interface{}) interface{} {
+	float := false
+	for _, arg := range args {
+		if _, ok := decimal.From(arg); ok {
+			return runtime.ToDecimal(result)
+		}
+		float = float || isFloat(arg)
+	}
+	if float {
+		return runtime.ToFloat64(result)
+	}
+	return runtime.ToInt(result)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/antonmedv/expr/builtin"
 	"github.com/antonmedv/expr/util/checking"
 	. "github.com/antonmedv/expr/util/typing"
+	"github.com/antonmedv/expr/vm/runtime"
 )
 
 func (f *F_len) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
@@ -39,9 +40,10 @@ func (f *F_one) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect
 
 func (f *F_filter) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	collection, _ := v.Visit(node.Arguments[0])
-	if !IsArray(collection) && !IsAny(collection) {
-		return v.Error(node.Arguments[0], "builtin %s takes only array (got %s)", node, collection)
+	if !IsArray(collection) && !IsMap(collection) && !IsAny(collection) {
+		return v.Error(node.Arguments[0], "builtin %s takes only array or map (got %s)", node, collection)
 	}
+	collection = iterable(collection)
 
 	v.AddCollection(collection)
 	closure, _ := v.Visit(node.Arguments[1])
@@ -64,9 +66,10 @@ func (f *F_filter) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (refl
 
 func (f *F_map) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	collection, _ := v.Visit(node.Arguments[0])
-	if !IsArray(collection) && !IsAny(collection) {
-		return v.Error(node.Arguments[0], "builtin %s takes only array (got %s)", node, collection)
+	if !IsArray(collection) && !IsMap(collection) && !IsAny(collection) {
+		return v.Error(node.Arguments[0], "builtin %s takes only array or map (got %s)", node, collection)
 	}
+	collection = iterable(collection)
 
 	v.AddCollection(collection)
 	closure, _ := v.Visit(node.Arguments[1])
@@ -83,9 +86,10 @@ func (f *F_map) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect
 
 func (f *F_count) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	collection, _ := v.Visit(node.Arguments[0])
-	if !IsArray(collection) && !IsAny(collection) {
-		return v.Error(node.Arguments[0], "builtin %s takes only array (got %s)", node, collection)
+	if !IsArray(collection) && !IsMap(collection) && !IsAny(collection) {
+		return v.Error(node.Arguments[0], "builtin %s takes only array or map (got %s)", node, collection)
 	}
+	collection = iterable(collection)
 
 	v.AddCollection(collection) // v.collections = append(v.collections, collection)
 	closure, _ := v.Visit(node.Arguments[1])
@@ -105,9 +109,10 @@ func (f *F_count) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (refle
 
 func visit_reducers(f builtin.Function, v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
 	collection, _ := v.Visit(node.Arguments[0])
-	if !IsArray(collection) && !IsAny(collection) {
-		return v.Error(node.Arguments[0], "builtin %s takes only array (got %s)", node, collection)
+	if !IsArray(collection) && !IsMap(collection) && !IsAny(collection) {
+		return v.Error(node.Arguments[0], "builtin %s takes only array or map (got %s)", node, collection)
 	}
+	collection = iterable(collection)
 
 	v.AddCollection(collection)
 	closure, _ := v.Visit(node.Arguments[1])
@@ -125,3 +130,320 @@ func visit_reducers(f builtin.Function, v *checking.ExternVisitor, node *ast.Bui
 
 	return v.Error(node.Arguments[1], "closure should has one input and one output param")
 }
+
+func (f *F_reduce) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, ok := visit_collection(v, node, node.Arguments[0])
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	acc := elem(collection)
+	if len(node.Arguments) == 3 {
+		// The initial value is outside of the closure, so # refers to the
+		// outer collection.
+		acc, _ = v.Visit(node.Arguments[2])
+	}
+	out, ok := visit_reducer(v, node, collection, acc)
+	if ok && out != acc && acc != nil && !IsAny(acc) {
+		// The accumulator changes its type after the first step, e.g. from
+		// int to float, so its type is unknown in the closure.
+		out, ok = visit_reducer(v, node, collection, AnyType)
+	}
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	return out, checking.Info{}
+}
+
+// visit_reducer checks the closure of reduce, in which #acc has the given
+// type. It returns the type of closure result.
+func visit_reducer(v *checking.ExternVisitor, node *ast.BuiltinNode, collection, acc reflect.Type) (reflect.Type, bool) {
+	v.AddReducer(collection, acc)
+	closure, _ := v.Visit(node.Arguments[1])
+	v.PopCollection()
+
+	if IsFunc(closure) &&
+		closure.NumOut() == 1 &&
+		closure.NumIn() == 1 && IsAny(closure.In(0)) {
+
+		return closure.Out(0), true
+	}
+
+	v.Error(node.Arguments[1], "closure should has one input and one output param")
+	return AnyType, false
+}
+
+func (f *F_sum) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	_, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if IsAny(out) {
+		return AnyType, checking.Info{}
+	}
+	if IsDecimal(out) {
+		return DecimalType, checking.Info{}
+	}
+	if IsFloat(out) {
+		return FloatType, checking.Info{}
+	}
+	if IsInteger(out) {
+		return IntegerType, checking.Info{}
+	}
+	return v.Error(node, "builtin %s takes only numbers (got %s)", node, out)
+}
+
+func (f *F_avg) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	_, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if !IsNumber(out) && !IsAny(out) {
+		return v.Error(node, "builtin %s takes only numbers (got %s)", node, out)
+	}
+	return FloatType, checking.Info{}
+}
+
+func (f *F_min) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_ordered(v, node)
+}
+
+func (f *F_max) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_ordered(v, node)
+}
+
+func (f *F_sortBy) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if !isOrdered(out) {
+		return v.Error(node.Arguments[1], "closure should return number, string or time (got %s)", out)
+	}
+	return collection, checking.Info{}
+}
+
+func (f *F_groupBy) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	if _, _, ok := visit_closure(v, node); !ok {
+		return AnyType, checking.Info{}
+	}
+	return reflect.TypeOf(map[interface{}][]interface{}{}), checking.Info{}
+}
+
+func (f *F_uniq) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, _, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	return collection, checking.Info{}
+}
+
+func (f *F_flatten) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	if _, ok := visit_collection(v, node, node.Arguments[0]); !ok {
+		return AnyType, checking.Info{}
+	}
+	return ArrayType, checking.Info{}
+}
+
+func (f *F_first) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, ok := visit_collection(v, node, node.Arguments[0])
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	return elem(collection), checking.Info{}
+}
+
+func (f *F_last) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, ok := visit_collection(v, node, node.Arguments[0])
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	return elem(collection), checking.Info{}
+}
+
+func (f *F_find) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if !IsBool(out) && !IsAny(out) {
+		return v.Error(node.Arguments[1], "closure should return boolean (got %s)", out)
+	}
+	return elem(collection), checking.Info{}
+}
+
+func (f *F_findIndex) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	_, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if !IsBool(out) && !IsAny(out) {
+		return v.Error(node.Arguments[1], "closure should return boolean (got %s)", out)
+	}
+	return IntegerType, checking.Info{}
+}
+
+func (f *F_take) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_slicing(v, node)
+}
+
+func (f *F_drop) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_slicing(v, node)
+}
+
+func (f *F_zip) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	for _, arg := range node.Arguments {
+		if _, ok := visit_collection(v, node, arg); !ok {
+			return AnyType, checking.Info{}
+		}
+	}
+	return reflect.TypeOf([][]interface{}{}), checking.Info{}
+}
+
+func (f *F_keys) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	m, ok := visit_map(v, node)
+	if !ok || IsAny(m) {
+		return ArrayType, checking.Info{}
+	}
+	return reflect.SliceOf(m.Key()), checking.Info{}
+}
+
+func (f *F_values) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	m, ok := visit_map(v, node)
+	if !ok || IsAny(m) {
+		return ArrayType, checking.Info{}
+	}
+	return reflect.SliceOf(m.Elem()), checking.Info{}
+}
+
+func (f *F_entries) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	m, ok := visit_map(v, node)
+	if !ok || IsAny(m) {
+		return ArrayType, checking.Info{}
+	}
+	return iterable(m), checking.Info{}
+}
+
+func (f *F_int) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_conversion(v, node, IntegerType, IsNumber, IsString, IsBool)
+}
+
+func (f *F_float) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_conversion(v, node, FloatType, IsNumber, IsString, IsBool)
+}
+
+func (f *F_string) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	v.Visit(node.Arguments[0])
+	return StringType, checking.Info{}
+}
+
+func (f *F_bool) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_conversion(v, node, BoolType, IsBool, IsNumber, IsString)
+}
+
+func (f *F_type) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	v.Visit(node.Arguments[0])
+	return StringType, checking.Info{}
+}
+
+// visit_conversion checks that the argument of the call can be converted to
+// the out type, which is the type of the result.
+func visit_conversion(v *checking.ExternVisitor, node *ast.BuiltinNode, out reflect.Type, from ...TypeMatcher) (reflect.Type, checking.Info) {
+	param, _ := v.Visit(node.Arguments[0])
+	if IsAny(param) || MatchesAny(param, from...) {
+		return out, checking.Info{}
+	}
+	if param == NilType {
+		return v.Error(node.Arguments[0], "cannot convert nil to %s", node.Name)
+	}
+	return v.Error(node.Arguments[0], "cannot convert %s to %s", param, node.Name)
+}
+
+// visit_collection checks that arg of the call is an array.
+func visit_collection(v *checking.ExternVisitor, node *ast.BuiltinNode, arg ast.Node) (reflect.Type, bool) {
+	collection, _ := v.Visit(arg)
+	if !IsArray(collection) && !IsAny(collection) {
+		v.Error(arg, "builtin %s takes only array (got %s)", node, collection)
+		return AnyType, false
+	}
+	return collection, true
+}
+
+// visit_map checks that the argument of the call is a map.
+func visit_map(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, bool) {
+	m, _ := v.Visit(node.Arguments[0])
+	if m.Kind() != reflect.Map && !IsAny(m) {
+		v.Error(node.Arguments[0], "builtin %s takes only map (got %s)", node, m)
+		return AnyType, false
+	}
+	return m, true
+}
+
+// visit_closure checks the collection and the closure of the call. It returns
+// the type of the collection and the type of closure result. If the closure is
+// optional and missing, the result is the type of collection elements.
+func visit_closure(v *checking.ExternVisitor, node *ast.BuiltinNode) (collection reflect.Type, out reflect.Type, ok bool) {
+	collection, ok = visit_collection(v, node, node.Arguments[0])
+	if !ok {
+		return
+	}
+	if len(node.Arguments) < 2 {
+		return collection, elem(collection), true
+	}
+
+	v.AddCollection(collection)
+	closure, _ := v.Visit(node.Arguments[1])
+	v.PopCollection()
+
+	if IsFunc(closure) &&
+		closure.NumOut() == 1 &&
+		closure.NumIn() == 1 && IsAny(closure.In(0)) {
+
+		return collection, closure.Out(0), true
+	}
+
+	v.Error(node.Arguments[1], "closure should has one input and one output param")
+	return collection, AnyType, false
+}
+
+func visit_ordered(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	_, out, ok := visit_closure(v, node)
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	if !isOrdered(out) {
+		return v.Error(node, "builtin %s takes only numbers, strings or times (got %s)", node, out)
+	}
+	return out, checking.Info{}
+}
+
+func visit_slicing(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	collection, ok := visit_collection(v, node, node.Arguments[0])
+	if !ok {
+		return AnyType, checking.Info{}
+	}
+	n, _ := v.Visit(node.Arguments[1])
+	if !IsInteger(n) && !IsAny(n) {
+		return v.Error(node.Arguments[1], "builtin %s takes only integer as count (got %s)", node, n)
+	}
+	return collection, checking.Info{}
+}
+
+// iterable returns the type of collection, closures iterate over. Maps are
+// iterated as arrays of key-value entries.
+func iterable(collection reflect.Type) reflect.Type {
+	if collection.Kind() == reflect.Map {
+		return reflect.SliceOf(runtime.EntryType(collection))
+	}
+	return collection
+}
+
+func isOrdered(t reflect.Type) bool {
+	return IsNumber(t) || IsString(t) || IsTime(t) || IsAny(t)
+}
+
+func elem(collection reflect.Type) reflect.Type {
+	if IsAny(collection) {
+		return AnyType
+	}
+	return collection.Elem()
+}
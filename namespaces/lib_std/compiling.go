@@ -0,0 +1,295 @@
+package lib_std
+
+import (
+	"reflect"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
+	. "github.com/antonmedv/expr/util/typing"
+	. "github.com/antonmedv/expr/vm"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+func (f *F_all) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	var loopBreak int
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		loopBreak = c.EmitJump(OpJumpIfFalse)
+		c.Emit(OpPop)
+	})
+	c.Emit(OpTrue)
+	c.PatchJump(loopBreak)
+	c.Emit(OpEnd)
+}
+
+func (f *F_none) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	var loopBreak int
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		c.Emit(OpNot)
+		loopBreak = c.EmitJump(OpJumpIfFalse)
+		c.Emit(OpPop)
+	})
+	c.Emit(OpTrue)
+	c.PatchJump(loopBreak)
+	c.Emit(OpEnd)
+}
+
+func (f *F_any) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	var loopBreak int
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		loopBreak = c.EmitJump(OpJumpIfTrue)
+		c.Emit(OpPop)
+	})
+	c.Emit(OpFalse)
+	c.PatchJump(loopBreak)
+	c.Emit(OpEnd)
+}
+
+func (f *F_one) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		c.EmitCond(func() {
+			c.Emit(OpIncrementCount)
+		})
+	})
+	c.Emit(OpGetCount)
+	c.EmitPush(1)
+	c.Emit(OpEqual)
+	c.Emit(OpEnd)
+}
+
+func (f *F_filter) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		c.EmitCond(func() {
+			c.Emit(OpIncrementCount)
+			c.Emit(OpPointer)
+		})
+	})
+	c.Emit(OpGetCount)
+	c.Emit(OpEnd)
+	c.Emit(OpArray)
+}
+
+func (f *F_map) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+	})
+	c.Emit(OpGetLen)
+	c.Emit(OpEnd)
+	c.Emit(OpArray)
+}
+
+func (f *F_count) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		c.EmitCond(func() {
+			c.Emit(OpIncrementCount)
+		})
+	})
+	c.Emit(OpGetCount)
+	c.Emit(OpEnd)
+}
+
+func (f *F_reduce) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	if len(node.Arguments) == 3 {
+		// The initial value is compiled before the scope of reduce is opened,
+		// so # in it refers to the outer collection.
+		c.Compile(node.Arguments[2])
+		c.Compile(node.Arguments[0])
+		c.Emit(OpBegin)
+	} else {
+		// Without initial value the first element is used, and the loop starts
+		// from the second one.
+		c.Compile(node.Arguments[0])
+		c.Emit(OpBegin)
+		c.Emit(OpGetArray)
+		emit_call(c, node, 1, reduceFirst)
+		c.Emit(OpIncrementIt)
+	}
+	c.Emit(OpSetAcc)
+	c.EmitLoop(func() {
+		c.CompileReducer(node.Arguments[1])
+		c.Emit(OpSetAcc)
+	})
+	c.Emit(OpGetAcc)
+	c.Emit(OpEnd)
+}
+
+func (f *F_sum) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_sum(c, node)
+	c.Emit(OpEnd)
+}
+
+func (f *F_avg) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_sum(c, node)
+	c.Emit(OpGetArray)
+	emit_call(c, node, 1, avgCheck)
+	c.Emit(OpPop)
+	c.Emit(OpGetLen)
+	c.Emit(OpDivide)
+	c.Emit(OpEnd)
+}
+
+func (f *F_min) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_values(c, node)
+	emit_call(c, node, 1, minOf)
+}
+
+func (f *F_max) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_values(c, node)
+	emit_call(c, node, 1, maxOf)
+}
+
+func (f *F_sortBy) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_keys(c, node)
+	emit_call(c, node, 2, sortByKeys)
+}
+
+func (f *F_groupBy) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	emit_keys(c, node)
+	emit_call(c, node, 2, groupByKeys)
+}
+
+func (f *F_uniq) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	if len(node.Arguments) == 2 {
+		emit_keys(c, node)
+		emit_call(c, node, 2, uniqByKeys)
+		return
+	}
+	c.Compile(node.Arguments[0])
+	emit_call(c, node, 1, func(args ...interface{}) (interface{}, error) {
+		return uniqByKeys(args[0], args[0])
+	})
+}
+
+func (f *F_find) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	var loopBreak int
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		loopBreak = c.EmitJump(OpJumpIfTrue)
+		c.Emit(OpPop)
+	})
+	c.Emit(OpNil)
+	end := c.EmitJump(OpJump)
+	c.PatchJump(loopBreak)
+	c.Emit(OpPop)
+	c.Emit(OpPointer)
+	c.PatchJump(end)
+	c.Emit(OpEnd)
+}
+
+func (f *F_findIndex) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	var loopBreak int
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+		loopBreak = c.EmitJump(OpJumpIfTrue)
+		c.Emit(OpPop)
+		// Count of skipped elements is the index of found one.
+		c.Emit(OpIncrementCount)
+	})
+	c.EmitPush(-1)
+	end := c.EmitJump(OpJump)
+	c.PatchJump(loopBreak)
+	c.Emit(OpPop)
+	c.Emit(OpGetCount)
+	c.PatchJump(end)
+	c.Emit(OpEnd)
+}
+
+// emit_sum emits a loop adding elements of the collection (or results of the
+// optional closure) to the accumulator. The scope must be closed by caller.
+func emit_sum(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitPush(0)
+	c.Emit(OpSetAcc)
+	c.EmitLoop(func() {
+		c.Emit(OpGetAcc)
+		if len(node.Arguments) == 2 {
+			c.Compile(node.Arguments[1])
+		} else {
+			c.Emit(OpPointer)
+		}
+		c.Emit(OpAdd)
+		c.Emit(OpSetAcc)
+	})
+	c.Emit(OpGetAcc)
+}
+
+// emit_values pushes the collection, or the array of results of the optional
+// closure (like map does).
+func emit_values(c builtin.Emitter, node *ast.BuiltinNode) {
+	if len(node.Arguments) == 2 {
+		(&F_map{}).Compile(c, node)
+		return
+	}
+	c.Compile(node.Arguments[0])
+}
+
+// emit_keys pushes the array of closure results and the collection itself.
+func emit_keys(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	c.Emit(OpBegin)
+	c.EmitLoop(func() {
+		c.Compile(node.Arguments[1])
+	})
+	c.Emit(OpGetLen)
+	c.Emit(OpArray)
+	c.Emit(OpGetArray)
+	c.Emit(OpEnd)
+}
+
+// Numbers are converted with OpCast, other values are parsed by Run.
+func (f *F_int) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	if castable(node.Arguments[0].Type()) {
+		c.Emit(OpCast, 0)
+		return
+	}
+	emit_call(c, node, 1, f.Run)
+}
+
+func (f *F_float) Compile(c builtin.Emitter, node *ast.BuiltinNode) {
+	c.Compile(node.Arguments[0])
+	if castable(node.Arguments[0].Type()) {
+		c.Emit(OpCast, 2)
+		return
+	}
+	emit_call(c, node, 1, f.Run)
+}
+
+// castable reports whether OpCast supports values of the type. Named
+// numbers, like time.Duration, are not supported.
+func castable(t reflect.Type) bool {
+	return IsDecimal(t) || IsNumber(t) && t.PkgPath() == ""
+}
+
+// emit_call emits a call of fn with arity arguments from the stack.
+func emit_call(c builtin.Emitter, node *ast.BuiltinNode, arity int, fn func(args ...interface{}) (interface{}, error)) {
+	c.Emit(OpCallBuiltin, c.AddConstant(&runtime.Builtin{
+		Name:  node.String(),
+		Arity: arity,
+		Func:  fn,
+	}))
+}
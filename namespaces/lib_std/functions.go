@@ -11,6 +11,22 @@ var arg_expression_and_closure []builtin.Argument = []builtin.Argument{
 	{ParserType: builtin.Closure},
 }
 
+var arg_expression_and_optional_closure []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Closure, Optional: true},
+}
+
+var arg_expression_closure_and_optional []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Closure},
+	{ParserType: builtin.Expression, Optional: true},
+}
+
+var arg_two_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
 type ExpressionClosureAccepting struct {
 	builtin.BaseFunc
 }
@@ -19,10 +35,43 @@ type F_len struct {
 	builtin.BaseFunc
 }
 
+// ConversionAccepting is a foldable function of one expression.
+type ConversionAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *ConversionAccepting) Arguments() []builtin.Argument {
+	return arg_expression
+}
+
 func (e *ExpressionClosureAccepting) Arguments() []builtin.Argument {
 	return arg_expression_and_closure
 }
 
+type ExpressionAccepting struct {
+	builtin.BaseFunc
+}
+
+func (e *ExpressionAccepting) Arguments() []builtin.Argument {
+	return arg_expression
+}
+
+type OptionalClosureAccepting struct {
+	builtin.BaseFunc
+}
+
+func (e *OptionalClosureAccepting) Arguments() []builtin.Argument {
+	return arg_expression_and_optional_closure
+}
+
+type TwoExpressionsAccepting struct {
+	builtin.BaseFunc
+}
+
+func (e *TwoExpressionsAccepting) Arguments() []builtin.Argument {
+	return arg_two_expressions
+}
+
 func (f *F_len) Name() string {
 	return "len"
 }
@@ -86,3 +135,199 @@ type F_count struct {
 func (f *F_count) Name() string {
 	return "count"
 }
+
+type F_reduce struct {
+	builtin.BaseFunc
+}
+
+func (f *F_reduce) Name() string {
+	return "reduce"
+}
+
+func (f *F_reduce) Arguments() []builtin.Argument {
+	return arg_expression_closure_and_optional
+}
+
+type F_sum struct {
+	OptionalClosureAccepting
+}
+
+func (f *F_sum) Name() string {
+	return "sum"
+}
+
+type F_avg struct {
+	OptionalClosureAccepting
+}
+
+func (f *F_avg) Name() string {
+	return "avg"
+}
+
+type F_min struct {
+	OptionalClosureAccepting
+}
+
+func (f *F_min) Name() string {
+	return "min"
+}
+
+type F_max struct {
+	OptionalClosureAccepting
+}
+
+func (f *F_max) Name() string {
+	return "max"
+}
+
+type F_sortBy struct {
+	ExpressionClosureAccepting
+}
+
+func (f *F_sortBy) Name() string {
+	return "sortBy"
+}
+
+type F_groupBy struct {
+	ExpressionClosureAccepting
+}
+
+func (f *F_groupBy) Name() string {
+	return "groupBy"
+}
+
+type F_uniq struct {
+	OptionalClosureAccepting
+}
+
+func (f *F_uniq) Name() string {
+	return "uniq"
+}
+
+type F_flatten struct {
+	ExpressionAccepting
+}
+
+func (f *F_flatten) Name() string {
+	return "flatten"
+}
+
+type F_first struct {
+	ExpressionAccepting
+}
+
+func (f *F_first) Name() string {
+	return "first"
+}
+
+type F_last struct {
+	ExpressionAccepting
+}
+
+func (f *F_last) Name() string {
+	return "last"
+}
+
+type F_find struct {
+	ExpressionClosureAccepting
+}
+
+func (f *F_find) Name() string {
+	return "find"
+}
+
+type F_findIndex struct {
+	ExpressionClosureAccepting
+}
+
+func (f *F_findIndex) Name() string {
+	return "findIndex"
+}
+
+type F_take struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_take) Name() string {
+	return "take"
+}
+
+type F_drop struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_drop) Name() string {
+	return "drop"
+}
+
+type F_zip struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_zip) Name() string {
+	return "zip"
+}
+
+type F_keys struct {
+	ExpressionAccepting
+}
+
+func (f *F_keys) Name() string {
+	return "keys"
+}
+
+type F_values struct {
+	ExpressionAccepting
+}
+
+func (f *F_values) Name() string {
+	return "values"
+}
+
+type F_entries struct {
+	ExpressionAccepting
+}
+
+func (f *F_entries) Name() string {
+	return "entries"
+}
+
+type F_int struct {
+	ConversionAccepting
+}
+
+func (f *F_int) Name() string {
+	return "int"
+}
+
+type F_float struct {
+	ConversionAccepting
+}
+
+func (f *F_float) Name() string {
+	return "float"
+}
+
+type F_string struct {
+	ConversionAccepting
+}
+
+func (f *F_string) Name() string {
+	return "string"
+}
+
+type F_bool struct {
+	ConversionAccepting
+}
+
+func (f *F_bool) Name() string {
+	return "bool"
+}
+
+type F_type struct {
+	ConversionAccepting
+}
+
+func (f *F_type) Name() string {
+	return "type"
+}
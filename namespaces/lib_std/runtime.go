@@ -0,0 +1,409 @@
+package lib_std
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antonmedv/expr/decimal"
+	"github.com/antonmedv/expr/file"
+	. "github.com/antonmedv/expr/util/typing"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+func (f *F_len) Run(args ...interface{}) (interface{}, error) {
+	return runtime.Length(args[0]), nil
+}
+
+func (f *F_flatten) Run(args ...interface{}) (interface{}, error) {
+	v, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.ValueOf(v.Index(i).Interface())
+		switch item.Kind() {
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < item.Len(); j++ {
+				out = append(out, item.Index(j).Interface())
+			}
+		default:
+			out = append(out, v.Index(i).Interface())
+		}
+	}
+	return out, nil
+}
+
+func (f *F_first) Run(args ...interface{}) (interface{}, error) {
+	v, err := nonEmpty("first", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return v.Index(0).Interface(), nil
+}
+
+func (f *F_last) Run(args ...interface{}) (interface{}, error) {
+	v, err := nonEmpty("last", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return v.Index(v.Len() - 1).Interface(), nil
+}
+
+func (f *F_take) Run(args ...interface{}) (interface{}, error) {
+	v, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return slice(v, 0, clamp(runtime.ToInt(args[1]), v.Len())), nil
+}
+
+func (f *F_drop) Run(args ...interface{}) (interface{}, error) {
+	v, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return slice(v, clamp(runtime.ToInt(args[1]), v.Len()), v.Len()), nil
+}
+
+func (f *F_zip) Run(args ...interface{}) (interface{}, error) {
+	a, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := array(args[1])
+	if err != nil {
+		return nil, err
+	}
+	size := a.Len()
+	if b.Len() < size {
+		size = b.Len()
+	}
+	out := make([][]interface{}, size)
+	for i := range out {
+		out[i] = []interface{}{a.Index(i).Interface(), b.Index(i).Interface()}
+	}
+	return out, nil
+}
+
+func (f *F_keys) Run(args ...interface{}) (interface{}, error) {
+	m, err := mapping(args[0])
+	if err != nil {
+		return nil, err
+	}
+	keys := runtime.MapKeys(m)
+	out := reflect.MakeSlice(reflect.SliceOf(m.Type().Key()), len(keys), len(keys))
+	for i, key := range keys {
+		out.Index(i).Set(key)
+	}
+	return out.Interface(), nil
+}
+
+func (f *F_values) Run(args ...interface{}) (interface{}, error) {
+	m, err := mapping(args[0])
+	if err != nil {
+		return nil, err
+	}
+	keys := runtime.MapKeys(m)
+	out := reflect.MakeSlice(reflect.SliceOf(m.Type().Elem()), len(keys), len(keys))
+	for i, key := range keys {
+		out.Index(i).Set(m.MapIndex(key))
+	}
+	return out.Interface(), nil
+}
+
+func (f *F_entries) Run(args ...interface{}) (interface{}, error) {
+	m, err := mapping(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return runtime.Entries(m).Interface(), nil
+}
+
+func (f *F_int) Run(args ...interface{}) (interface{}, error) {
+	switch x := args[0].(type) {
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(x))
+		if err != nil {
+			return nil, runtime.Errorf(file.InvalidArgument, "cannot convert %q to int", x)
+		}
+		return n, nil
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	n, ok := number(args[0])
+	if !ok {
+		return nil, cannotConvert(args[0], "int")
+	}
+	i, err := runtime.ToIntChecked(n)
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (f *F_float) Run(args ...interface{}) (interface{}, error) {
+	switch x := args[0].(type) {
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return nil, runtime.Errorf(file.InvalidArgument, "cannot convert %q to float", x)
+		}
+		return n, nil
+	case bool:
+		if x {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	}
+	n, ok := number(args[0])
+	if !ok {
+		return nil, cannotConvert(args[0], "float")
+	}
+	return runtime.ToFloat64(n), nil
+}
+
+// cannotConvert is the error of a conversion, with the same message as
+// the checker reports for the type of x.
+func cannotConvert(x interface{}, to string) error {
+	if x == nil {
+		return runtime.Errorf(file.InvalidArgument, "cannot convert nil to %s", to)
+	}
+	return runtime.Errorf(file.InvalidArgument, "cannot convert %T to %s", x, to)
+}
+
+func (f *F_string) Run(args ...interface{}) (interface{}, error) {
+	// Same as the interpolation in template strings, nil is "<nil>".
+	return runtime.Concat(args[:1]), nil
+}
+
+func (f *F_bool) Run(args ...interface{}) (interface{}, error) {
+	switch x := args[0].(type) {
+	case bool:
+		return x, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(x))
+		if err != nil {
+			return nil, runtime.Errorf(file.InvalidArgument, "cannot convert %q to bool", x)
+		}
+		return b, nil
+	}
+	n, ok := number(args[0])
+	if !ok {
+		return nil, cannotConvert(args[0], "bool")
+	}
+	return runtime.ToFloat64(n) != 0, nil
+}
+
+func (f *F_type) Run(args ...interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return "nil", nil
+	}
+	t := reflect.TypeOf(args[0])
+	switch {
+	case IsDecimal(t):
+		return "decimal", nil
+	case t == DurationType:
+		return "duration", nil
+	case t == TimeType:
+		return "time", nil
+	case IsInteger(t):
+		return "int", nil
+	case IsFloat(t):
+		return "float", nil
+	case IsString(t):
+		return "string", nil
+	case IsBool(t):
+		return "bool", nil
+	case IsArray(t):
+		return "array", nil
+	case IsMap(t):
+		return "map", nil
+	case IsFunc(t):
+		return "func", nil
+	}
+	return t.String(), nil
+}
+
+// nonEmpty returns the array, which must not be empty, as the result of
+// the builtin would be missing otherwise.
+func nonEmpty(name string, a interface{}) (reflect.Value, error) {
+	v, err := array(a)
+	if err != nil {
+		return v, err
+	}
+	if v.Len() == 0 {
+		return v, fmt.Errorf("%s of empty array", name)
+	}
+	return v, nil
+}
+
+// reduceFirst returns the first element of args[0], which is the initial
+// value of reduce without one.
+func reduceFirst(args ...interface{}) (interface{}, error) {
+	v, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if v.Len() == 0 {
+		return nil, fmt.Errorf("reduce of empty array with no initial value")
+	}
+	return v.Index(0).Interface(), nil
+}
+
+func minOf(args ...interface{}) (interface{}, error) {
+	return extremum("min", args[0], runtime.Less)
+}
+
+func maxOf(args ...interface{}) (interface{}, error) {
+	return extremum("max", args[0], runtime.More)
+}
+
+// avgCheck fails for the empty array, which has no average.
+func avgCheck(args ...interface{}) (interface{}, error) {
+	_, err := nonEmpty("avg", args[0])
+	return nil, err
+}
+
+// sortByKeys returns elements of args[1] sorted by keys from args[0].
+func sortByKeys(args ...interface{}) (interface{}, error) {
+	keys := args[0].([]interface{})
+	v, err := array(args[1])
+	if err != nil {
+		return nil, err
+	}
+	index := make([]int, len(keys))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		return runtime.Less(keys[index[i]], keys[index[j]])
+	})
+	out := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(index), len(index))
+	for i, j := range index {
+		out.Index(i).Set(v.Index(j))
+	}
+	return out.Interface(), nil
+}
+
+// groupByKeys groups elements of args[1] by keys from args[0].
+func groupByKeys(args ...interface{}) (interface{}, error) {
+	keys := args[0].([]interface{})
+	v, err := array(args[1])
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[interface{}][]interface{})
+	for i, key := range keys {
+		if !hashable(key) {
+			return nil, runtime.Errorf(file.TypeMismatch, "cannot use %T as group key", key)
+		}
+		out[key] = append(out[key], v.Index(i).Interface())
+	}
+	return out, nil
+}
+
+// uniqByKeys returns elements of args[1] with keys from args[0] seen for the
+// first time.
+func uniqByKeys(args ...interface{}) (interface{}, error) {
+	keys, err := array(args[0])
+	if err != nil {
+		return nil, err
+	}
+	v, err := array(args[1])
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[interface{}]struct{})
+	out := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, 0)
+	for i := 0; i < keys.Len(); i++ {
+		key := keys.Index(i).Interface()
+		if !hashable(key) {
+			return nil, runtime.Errorf(file.TypeMismatch, "cannot use %T as uniq key", key)
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = reflect.Append(out, v.Index(i))
+	}
+	return out.Interface(), nil
+}
+
+func array(a interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(a)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v, nil
+	}
+	return v, runtime.Errorf(file.TypeMismatch, "cannot use %T as array", a)
+}
+
+func mapping(a interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Map {
+		return v, runtime.Errorf(file.TypeMismatch, "cannot use %T as map", a)
+	}
+	return v, nil
+}
+
+// slice returns a copy of v[from:to], which works for not addressable arrays too.
+func slice(v reflect.Value, from, to int) interface{} {
+	out := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), to-from, to-from)
+	for i := from; i < to; i++ {
+		out.Index(i - from).Set(v.Index(i))
+	}
+	return out.Interface()
+}
+
+func clamp(n, size int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > size {
+		return size
+	}
+	return n
+}
+
+func extremum(name string, a interface{}, better func(a, b interface{}) bool) (interface{}, error) {
+	v, err := nonEmpty(name, a)
+	if err != nil {
+		return nil, err
+	}
+	result := v.Index(0).Interface()
+	for i := 1; i < v.Len(); i++ {
+		if item := v.Index(i).Interface(); better(item, result) {
+			result = item
+		}
+	}
+	return result, nil
+}
+
+// number returns the number as int64, uint64, float64 or decimal, which
+// runtime conversions support, also for named types like time.Duration.
+func number(x interface{}) (interface{}, bool) {
+	if d, ok := decimal.From(x); ok {
+		return d, true
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return nil, false
+}
+
+func hashable(key interface{}) bool {
+	return key == nil || reflect.TypeOf(key).Comparable()
+}
@@ -15,6 +15,30 @@ func NewBuiltinStandard() builtin.BuiltinNamespace {
 			&F_filter{},
 			&F_map{},
 			&F_count{},
+			&F_reduce{},
+			&F_sum{},
+			&F_avg{},
+			&F_min{},
+			&F_max{},
+			&F_sortBy{},
+			&F_groupBy{},
+			&F_uniq{},
+			&F_flatten{},
+			&F_first{},
+			&F_last{},
+			&F_find{},
+			&F_findIndex{},
+			&F_take{},
+			&F_drop{},
+			&F_zip{},
+			&F_keys{},
+			&F_values{},
+			&F_entries{},
+			&F_int{},
+			&F_float{},
+			&F_string{},
+			&F_bool{},
+			&F_type{},
 		),
 	}
 }
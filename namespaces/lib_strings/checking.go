@@ -0,0 +1,95 @@
+package lib_strings
+
+import (
+	"reflect"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/util/checking"
+	. "github.com/antonmedv/expr/util/typing"
+)
+
+var stringArrayType = reflect.TypeOf([]string{})
+
+type param struct {
+	name    string
+	matches func(t reflect.Type) bool
+}
+
+var (
+	str      = param{"string", IsString}
+	integer  = param{"integer", IsInteger}
+	strArray = param{"array of strings", func(t reflect.Type) bool {
+		return IsArray(t) && (IsString(t.Elem()) || IsAny(t.Elem()))
+	}}
+)
+
+func (f *F_upper) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str)
+}
+
+func (f *F_lower) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str)
+}
+
+func (f *F_title) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str)
+}
+
+func (f *F_trim) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, str)
+}
+
+func (f *F_trimPrefix) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, str)
+}
+
+func (f *F_trimSuffix) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, str)
+}
+
+func (f *F_split) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, stringArrayType, str, str)
+}
+
+func (f *F_join) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, strArray, str)
+}
+
+func (f *F_replace) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, str, str)
+}
+
+func (f *F_indexOf) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, str, str)
+}
+
+func (f *F_lastIndexOf) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, str, str)
+}
+
+func (f *F_repeat) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, integer)
+}
+
+func (f *F_padLeft) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, integer, str)
+}
+
+func (f *F_padRight) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, str, integer, str)
+}
+
+func (f *F_length) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, str)
+}
+
+// visit_params checks arguments of the call against params and returns result type.
+func visit_params(v *checking.ExternVisitor, node *ast.BuiltinNode, result reflect.Type, params ...param) (reflect.Type, checking.Info) {
+	for i, arg := range node.Arguments {
+		t, _ := v.Visit(arg)
+		if !params[i].matches(t) && !IsAny(t) {
+			return v.Error(arg, "%s.%s expects %s as argument %d (got %v)", node.Namespace, node.Name, params[i].name, i+1, t)
+		}
+	}
+	return result, checking.Info{}
+}
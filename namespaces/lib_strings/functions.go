@@ -0,0 +1,191 @@
+package lib_strings
+
+import "github.com/antonmedv/expr/builtin"
+
+var arg_one_expression []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+}
+
+var arg_two_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_three_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_expression_and_optional []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression, Optional: true},
+}
+
+var arg_two_expressions_and_optional []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression, Optional: true},
+}
+
+type OneExpressionAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *OneExpressionAccepting) Arguments() []builtin.Argument {
+	return arg_one_expression
+}
+
+type TwoExpressionsAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *TwoExpressionsAccepting) Arguments() []builtin.Argument {
+	return arg_two_expressions
+}
+
+type OptionalSecondAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *OptionalSecondAccepting) Arguments() []builtin.Argument {
+	return arg_expression_and_optional
+}
+
+type F_upper struct {
+	OneExpressionAccepting
+}
+
+func (f *F_upper) Name() string {
+	return "upper"
+}
+
+type F_lower struct {
+	OneExpressionAccepting
+}
+
+func (f *F_lower) Name() string {
+	return "lower"
+}
+
+type F_title struct {
+	OneExpressionAccepting
+}
+
+func (f *F_title) Name() string {
+	return "title"
+}
+
+type F_trim struct {
+	OptionalSecondAccepting
+}
+
+func (f *F_trim) Name() string {
+	return "trim"
+}
+
+type F_trimPrefix struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_trimPrefix) Name() string {
+	return "trimPrefix"
+}
+
+type F_trimSuffix struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_trimSuffix) Name() string {
+	return "trimSuffix"
+}
+
+type F_split struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_split) Name() string {
+	return "split"
+}
+
+type F_join struct {
+	OptionalSecondAccepting
+}
+
+func (f *F_join) Name() string {
+	return "join"
+}
+
+type F_replace struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_replace) Name() string {
+	return "replace"
+}
+
+func (f *F_replace) Arguments() []builtin.Argument {
+	return arg_three_expressions
+}
+
+type F_indexOf struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_indexOf) Name() string {
+	return "indexOf"
+}
+
+type F_lastIndexOf struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_lastIndexOf) Name() string {
+	return "lastIndexOf"
+}
+
+// F_repeat, F_padLeft and F_padRight are not foldable, so that their
+// results are allocated only at runtime, within the memory budget.
+type F_repeat struct {
+	builtin.BaseFunc
+}
+
+func (f *F_repeat) Name() string {
+	return "repeat"
+}
+
+func (f *F_repeat) Arguments() []builtin.Argument {
+	return arg_two_expressions
+}
+
+type F_padLeft struct {
+	builtin.BaseFunc
+}
+
+func (f *F_padLeft) Name() string {
+	return "padLeft"
+}
+
+func (f *F_padLeft) Arguments() []builtin.Argument {
+	return arg_two_expressions_and_optional
+}
+
+type F_padRight struct {
+	builtin.BaseFunc
+}
+
+func (f *F_padRight) Name() string {
+	return "padRight"
+}
+
+func (f *F_padRight) Arguments() []builtin.Argument {
+	return arg_two_expressions_and_optional
+}
+
+type F_length struct {
+	OneExpressionAccepting
+}
+
+func (f *F_length) Name() string {
+	return "length"
+}
@@ -0,0 +1,159 @@
+package lib_strings
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/vm"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+func (f *F_upper) Run(args ...interface{}) (interface{}, error) {
+	return strings.ToUpper(toString(args[0])), nil
+}
+
+func (f *F_lower) Run(args ...interface{}) (interface{}, error) {
+	return strings.ToLower(toString(args[0])), nil
+}
+
+func (f *F_title) Run(args ...interface{}) (interface{}, error) {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		word := unicode.IsLetter(prev) || unicode.IsDigit(prev) || prev == '_' || prev == '\''
+		prev = r
+		if word {
+			return r
+		}
+		return unicode.ToTitle(r)
+	}, toString(args[0])), nil
+}
+
+func (f *F_trim) Run(args ...interface{}) (interface{}, error) {
+	if len(args) == 2 {
+		return strings.Trim(toString(args[0]), toString(args[1])), nil
+	}
+	return strings.TrimSpace(toString(args[0])), nil
+}
+
+func (f *F_trimPrefix) Run(args ...interface{}) (interface{}, error) {
+	return strings.TrimPrefix(toString(args[0]), toString(args[1])), nil
+}
+
+func (f *F_trimSuffix) Run(args ...interface{}) (interface{}, error) {
+	return strings.TrimSuffix(toString(args[0]), toString(args[1])), nil
+}
+
+func (f *F_split) Run(args ...interface{}) (interface{}, error) {
+	return strings.Split(toString(args[0]), toString(args[1])), nil
+}
+
+func (f *F_join) Run(args ...interface{}) (interface{}, error) {
+	v := reflect.ValueOf(args[0])
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("strings.join expects array of strings (got %T)", args[0])
+	}
+	elems := make([]string, v.Len())
+	for i := range elems {
+		s, ok := v.Index(i).Interface().(string)
+		if !ok {
+			return nil, fmt.Errorf("strings.join expects array of strings (got %T at index %d)", v.Index(i).Interface(), i)
+		}
+		elems[i] = s
+	}
+	sep := ""
+	if len(args) == 2 {
+		sep = toString(args[1])
+	}
+	return strings.Join(elems, sep), nil
+}
+
+func (f *F_replace) Run(args ...interface{}) (interface{}, error) {
+	return strings.ReplaceAll(toString(args[0]), toString(args[1]), toString(args[2])), nil
+}
+
+func (f *F_indexOf) Run(args ...interface{}) (interface{}, error) {
+	s := toString(args[0])
+	return runeIndex(s, strings.Index(s, toString(args[1]))), nil
+}
+
+func (f *F_lastIndexOf) Run(args ...interface{}) (interface{}, error) {
+	s := toString(args[0])
+	return runeIndex(s, strings.LastIndex(s, toString(args[1]))), nil
+}
+
+func (f *F_repeat) Run(args ...interface{}) (interface{}, error) {
+	count := runtime.ToInt(args[1])
+	if count < 0 {
+		return nil, fmt.Errorf("strings.repeat count is negative (%d)", count)
+	}
+	s := toString(args[0])
+	if count > 0 && len(s) > vm.MemoryBudget/count {
+		return nil, errMemoryBudgetExceeded
+	}
+	return strings.Repeat(s, count), nil
+}
+
+func (f *F_padLeft) Run(args ...interface{}) (interface{}, error) {
+	s := toString(args[0])
+	pad, err := padding(s, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return pad + s, nil
+}
+
+func (f *F_padRight) Run(args ...interface{}) (interface{}, error) {
+	s := toString(args[0])
+	pad, err := padding(s, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return s + pad, nil
+}
+
+func (f *F_length) Run(args ...interface{}) (interface{}, error) {
+	return utf8.RuneCountInString(toString(args[0])), nil
+}
+
+func toString(x interface{}) string {
+	s, ok := x.(string)
+	if !ok {
+		panic(runtime.Errorf(file.TypeMismatch, "invalid operation: string(%T)", x))
+	}
+	return s
+}
+
+// runeIndex converts byte index i of s into index of rune.
+func runeIndex(s string, i int) int {
+	if i < 0 {
+		return i
+	}
+	return utf8.RuneCountInString(s[:i])
+}
+
+// padding returns the string, which extends s to the length given in args[0].
+// The padding is made of args[1], or spaces if it is missing.
+func padding(s string, args []interface{}) (string, error) {
+	pad := " "
+	if len(args) == 2 {
+		pad = toString(args[1])
+	}
+	width := runtime.ToInt(args[0])
+	if width >= vm.MemoryBudget {
+		return "", errMemoryBudgetExceeded
+	}
+	missing := width - utf8.RuneCountInString(s)
+	if missing <= 0 || pad == "" {
+		return "", nil
+	}
+	runes := []rune(strings.Repeat(pad, missing/utf8.RuneCountInString(pad)+1))
+	return string(runes[:missing]), nil
+}
+
+// errMemoryBudgetExceeded is returned for strings longer than the memory
+// budget of the VM, before they are allocated.
+var errMemoryBudgetExceeded = runtime.Errorf(file.MemoryBudgetExceeded, "memory budget exceeded")
@@ -0,0 +1,35 @@
+package lib_strings
+
+import (
+	"github.com/antonmedv/expr/builtin"
+)
+
+type BuiltinStrings struct {
+	builtin.BaseNamespace
+}
+
+func NewBuiltinStrings() builtin.BuiltinNamespace {
+	return &BuiltinStrings{
+		builtin.ContainerWith(
+			&F_upper{},
+			&F_lower{},
+			&F_title{},
+			&F_trim{},
+			&F_trimPrefix{},
+			&F_trimSuffix{},
+			&F_split{},
+			&F_join{},
+			&F_replace{},
+			&F_indexOf{},
+			&F_lastIndexOf{},
+			&F_repeat{},
+			&F_padLeft{},
+			&F_padRight{},
+			&F_length{},
+		),
+	}
+}
+
+func (b *BuiltinStrings) Name() string {
+	return "strings"
+}
@@ -0,0 +1,88 @@
+package lib_time
+
+import (
+	"reflect"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/util/checking"
+	. "github.com/antonmedv/expr/util/typing"
+)
+
+type param struct {
+	name    string
+	matches func(t reflect.Type) bool
+}
+
+var (
+	str      = param{"string", IsString}
+	integer  = param{"integer", IsInteger}
+	tm       = param{"time", IsTime}
+	duration = param{"duration", IsDuration}
+)
+
+func (f *F_now) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType)
+}
+
+func (f *F_parse) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, str, str)
+}
+
+func (f *F_format) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, StringType, tm, str)
+}
+
+func (f *F_duration) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, DurationType, str)
+}
+
+func (f *F_date) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, integer, integer, integer)
+}
+
+func (f *F_addDate) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, tm, integer, integer, integer)
+}
+
+func (f *F_truncate) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, tm, duration)
+}
+
+func (f *F_startOfDay) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, tm)
+}
+
+func (f *F_weekday) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, tm)
+}
+
+func (f *F_inLocation) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, tm, str)
+}
+
+func (f *F_unix) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, tm)
+}
+
+func (f *F_unixMilli) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, IntegerType, tm)
+}
+
+func (f *F_fromUnix) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, integer)
+}
+
+func (f *F_fromUnixMilli) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	return visit_params(v, node, TimeType, integer)
+}
+
+// visit_params checks arguments of the call against params and returns result type.
+func visit_params(v *checking.ExternVisitor, node *ast.BuiltinNode, result reflect.Type, params ...param) (reflect.Type, checking.Info) {
+	for i, arg := range node.Arguments {
+		t, _ := v.Visit(arg)
+		if !params[i].matches(t) && !IsAny(t) {
+			return v.Error(arg, "%s.%s expects %s as argument %d (got %v)", node.Namespace, node.Name, params[i].name, i+1, t)
+		}
+	}
+	return result, checking.Info{}
+}
@@ -0,0 +1,171 @@
+package lib_time
+
+import (
+	"time"
+
+	"github.com/antonmedv/expr/builtin"
+)
+
+var arg_one_expression []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+}
+
+var arg_two_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_three_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+var arg_four_expressions []builtin.Argument = []builtin.Argument{
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+	{ParserType: builtin.Expression},
+}
+
+type OneExpressionAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *OneExpressionAccepting) Arguments() []builtin.Argument {
+	return arg_one_expression
+}
+
+type TwoExpressionsAccepting struct {
+	builtin.BaseFoldableFunc
+}
+
+func (e *TwoExpressionsAccepting) Arguments() []builtin.Argument {
+	return arg_two_expressions
+}
+
+// F_now is not foldable, its value depends on the time of the run.
+type F_now struct {
+	builtin.BaseFunc
+	clock func() time.Time
+}
+
+func (f *F_now) Name() string {
+	return "now"
+}
+
+func (f *F_now) Arguments() []builtin.Argument {
+	return nil
+}
+
+type F_parse struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_parse) Name() string {
+	return "parse"
+}
+
+type F_format struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_format) Name() string {
+	return "format"
+}
+
+type F_duration struct {
+	OneExpressionAccepting
+}
+
+func (f *F_duration) Name() string {
+	return "duration"
+}
+
+type F_date struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_date) Name() string {
+	return "date"
+}
+
+func (f *F_date) Arguments() []builtin.Argument {
+	return arg_three_expressions
+}
+
+type F_addDate struct {
+	builtin.BaseFoldableFunc
+}
+
+func (f *F_addDate) Name() string {
+	return "addDate"
+}
+
+func (f *F_addDate) Arguments() []builtin.Argument {
+	return arg_four_expressions
+}
+
+type F_truncate struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_truncate) Name() string {
+	return "truncate"
+}
+
+type F_startOfDay struct {
+	OneExpressionAccepting
+}
+
+func (f *F_startOfDay) Name() string {
+	return "startOfDay"
+}
+
+type F_weekday struct {
+	OneExpressionAccepting
+}
+
+func (f *F_weekday) Name() string {
+	return "weekday"
+}
+
+type F_inLocation struct {
+	TwoExpressionsAccepting
+}
+
+func (f *F_inLocation) Name() string {
+	return "inLocation"
+}
+
+type F_unix struct {
+	OneExpressionAccepting
+}
+
+func (f *F_unix) Name() string {
+	return "unix"
+}
+
+type F_unixMilli struct {
+	OneExpressionAccepting
+}
+
+func (f *F_unixMilli) Name() string {
+	return "unixMilli"
+}
+
+type F_fromUnix struct {
+	OneExpressionAccepting
+}
+
+func (f *F_fromUnix) Name() string {
+	return "fromUnix"
+}
+
+type F_fromUnixMilli struct {
+	OneExpressionAccepting
+}
+
+func (f *F_fromUnixMilli) Name() string {
+	return "fromUnixMilli"
+}
@@ -0,0 +1,95 @@
+package lib_time
+
+import (
+	"time"
+
+	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+func (f *F_now) Run(_ ...interface{}) (interface{}, error) {
+	return f.clock(), nil
+}
+
+func (f *F_parse) Run(args ...interface{}) (interface{}, error) {
+	return time.Parse(toString(args[0]), toString(args[1]))
+}
+
+func (f *F_format) Run(args ...interface{}) (interface{}, error) {
+	return toTime(args[0]).Format(toString(args[1])), nil
+}
+
+func (f *F_duration) Run(args ...interface{}) (interface{}, error) {
+	return time.ParseDuration(toString(args[0]))
+}
+
+func (f *F_date) Run(args ...interface{}) (interface{}, error) {
+	year, month, day := runtime.ToInt(args[0]), runtime.ToInt(args[1]), runtime.ToInt(args[2])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (f *F_addDate) Run(args ...interface{}) (interface{}, error) {
+	years, months, days := runtime.ToInt(args[1]), runtime.ToInt(args[2]), runtime.ToInt(args[3])
+	return toTime(args[0]).AddDate(years, months, days), nil
+}
+
+func (f *F_truncate) Run(args ...interface{}) (interface{}, error) {
+	return toTime(args[0]).Truncate(toDuration(args[1])), nil
+}
+
+func (f *F_startOfDay) Run(args ...interface{}) (interface{}, error) {
+	t := toTime(args[0])
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+}
+
+func (f *F_weekday) Run(args ...interface{}) (interface{}, error) {
+	return int(toTime(args[0]).Weekday()), nil
+}
+
+func (f *F_inLocation) Run(args ...interface{}) (interface{}, error) {
+	loc, err := time.LoadLocation(toString(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return toTime(args[0]).In(loc), nil
+}
+
+func (f *F_unix) Run(args ...interface{}) (interface{}, error) {
+	return int(toTime(args[0]).Unix()), nil
+}
+
+func (f *F_unixMilli) Run(args ...interface{}) (interface{}, error) {
+	return int(toTime(args[0]).UnixNano() / int64(time.Millisecond)), nil
+}
+
+func (f *F_fromUnix) Run(args ...interface{}) (interface{}, error) {
+	return time.Unix(runtime.ToInt64(args[0]), 0).UTC(), nil
+}
+
+func (f *F_fromUnixMilli) Run(args ...interface{}) (interface{}, error) {
+	return time.Unix(0, runtime.ToInt64(args[0])*int64(time.Millisecond)).UTC(), nil
+}
+
+func toString(x interface{}) string {
+	s, ok := x.(string)
+	if !ok {
+		panic(runtime.Errorf(file.TypeMismatch, "invalid operation: string(%T)", x))
+	}
+	return s
+}
+
+func toTime(x interface{}) time.Time {
+	t, ok := x.(time.Time)
+	if !ok {
+		panic(runtime.Errorf(file.TypeMismatch, "invalid operation: time(%T)", x))
+	}
+	return t
+}
+
+func toDuration(x interface{}) time.Duration {
+	d, ok := x.(time.Duration)
+	if !ok {
+		panic(runtime.Errorf(file.TypeMismatch, "invalid operation: duration(%T)", x))
+	}
+	return d
+}
@@ -0,0 +1,42 @@
+package lib_time
+
+import (
+	"time"
+
+	"github.com/antonmedv/expr/builtin"
+)
+
+type BuiltinTime struct {
+	builtin.BaseNamespace
+}
+
+func NewBuiltinTime() builtin.BuiltinNamespace {
+	return NewBuiltinTimeWithClock(time.Now)
+}
+
+// NewBuiltinTimeWithClock returns the time namespace, which uses clock
+// as the source of time.now().
+func NewBuiltinTimeWithClock(clock func() time.Time) builtin.BuiltinNamespace {
+	return &BuiltinTime{
+		builtin.ContainerWith(
+			&F_now{clock: clock},
+			&F_parse{},
+			&F_format{},
+			&F_duration{},
+			&F_date{},
+			&F_addDate{},
+			&F_truncate{},
+			&F_startOfDay{},
+			&F_weekday{},
+			&F_inLocation{},
+			&F_unix{},
+			&F_unixMilli{},
+			&F_fromUnix{},
+			&F_fromUnixMilli{},
+		),
+	}
+}
+
+func (b *BuiltinTime) Name() string {
+	return "time"
+}
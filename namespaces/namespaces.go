@@ -3,17 +3,41 @@ package namespaces
 import (
 	"github.com/antonmedv/expr/builtin"
 	"github.com/antonmedv/expr/namespaces/lib_math"
+	"github.com/antonmedv/expr/namespaces/lib_strings"
+	"github.com/antonmedv/expr/namespaces/lib_time"
 )
 
-type namespaceMap map[string]builtin.BuiltinNamespace
+// Table maps names of builtin namespaces to their implementation.
+type Table map[string]builtin.BuiltinNamespace
 
-func (n namespaceMap) add(b builtin.BuiltinNamespace) {
-	n[b.Name()] = b
+func (t Table) Get(name string) (builtin.BuiltinNamespace, bool) {
+	b, ok := t[name]
+	return b, ok
 }
 
-var mapped namespaceMap = namespaceMap{}
+func (t Table) add(b builtin.BuiltinNamespace) {
+	t[b.Name()] = b
+}
+
+var mapped = Table{}
 
 func init() {
 	mapped.add(Stdlib)
 	mapped.add(lib_math.NewBuiltinMath())
+	mapped.add(lib_strings.NewBuiltinStrings())
+	mapped.add(lib_time.NewBuiltinTime())
+}
+
+// With returns a table of the builtin namespaces extended by the given ones.
+// Global namespaces are never modified, so every compilation can use its own
+// set of namespaces.
+func With(extra map[string]builtin.BuiltinNamespace) Table {
+	t := make(Table, len(mapped)+len(extra))
+	for name, b := range mapped {
+		t[name] = b
+	}
+	for name, b := range extra {
+		t[name] = b
+	}
+	return t
 }
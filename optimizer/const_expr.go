@@ -7,6 +7,7 @@ import (
 
 	. "github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/vm/runtime"
 )
 
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
@@ -25,6 +26,7 @@ func (c *constExpr) Visit(node *Node) {
 			msg = strings.Replace(msg, "runtime error:", "compile error:", 1)
 			c.err = &file.Error{
 				Location: (*node).Location(),
+				Code:     runtime.ErrorCode(r),
 				Message:  msg,
 			}
 		}
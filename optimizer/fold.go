@@ -1,16 +1,24 @@
 package optimizer
 
 import (
+	"fmt"
 	"math"
 	"reflect"
+	"strings"
 
 	. "github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
+	"github.com/antonmedv/expr/decimal"
 	"github.com/antonmedv/expr/file"
+	"github.com/antonmedv/expr/namespaces"
+	"github.com/antonmedv/expr/vm/runtime"
 )
 
 type fold struct {
-	applied bool
-	err     *file.Error
+	applied    bool
+	err        *file.Error
+	namespaces namespaces.Table
+	decimal    bool // float literals and division must not be folded as floats
 }
 
 func (fold *fold) Visit(node *Node) {
@@ -44,7 +52,22 @@ func (fold *fold) Visit(node *Node) {
 			}
 		}
 
+	case *TemplateNode:
+		for _, part := range n.Parts {
+			if toString(part) == nil {
+				return
+			}
+		}
+		var b strings.Builder
+		for _, part := range n.Parts {
+			b.WriteString(toString(part).Value)
+		}
+		patch(&StringNode{Value: b.String()})
+
 	case *BinaryNode:
+		if fold.decimal && (n.Operator == "/" || n.Operator == "**" || n.Operator == "^" || toFloat(n.Left) != nil || toFloat(n.Right) != nil) {
+			return
+		}
 		switch n.Operator {
 		case "+":
 			{
@@ -175,6 +198,7 @@ func (fold *fold) Visit(node *Node) {
 					if b.Value == 0 {
 						fold.err = &file.Error{
 							Location: (*node).Location(),
+							Code:     file.DivisionByZero,
 							Message:  "integer divide by zero",
 						}
 						return
@@ -182,6 +206,45 @@ func (fold *fold) Visit(node *Node) {
 					patch(&IntegerNode{Value: a.Value % b.Value})
 				}
 			}
+		case "div", "&", "|", "xor", "<<", ">>":
+			if a, ok := n.Left.(*IntegerNode); ok {
+				if b, ok := n.Right.(*IntegerNode); ok {
+					var value int
+					switch n.Operator {
+					case "div":
+						if b.Value == 0 {
+							fold.err = &file.Error{
+								Location: (*node).Location(),
+								Code:     file.DivisionByZero,
+								Message:  "integer divide by zero",
+							}
+							return
+						}
+						value = a.Value / b.Value
+					case "&":
+						value = a.Value & b.Value
+					case "|":
+						value = a.Value | b.Value
+					case "xor":
+						value = a.Value ^ b.Value
+					case "<<", ">>":
+						if b.Value < 0 {
+							fold.err = &file.Error{
+								Location: (*node).Location(),
+								Code:     file.InvalidArgument,
+								Message:  "negative shift amount",
+							}
+							return
+						}
+						if n.Operator == "<<" {
+							value = a.Value << uint(b.Value)
+						} else {
+							value = a.Value >> uint(b.Value)
+						}
+					}
+					patch(&IntegerNode{Value: value})
+				}
+			}
 		case "**", "^":
 			{
 				a := toInteger(n.Left)
@@ -229,7 +292,11 @@ func (fold *fold) Visit(node *Node) {
 				case *IntegerNode:
 					value[i] = b.Value
 				case *FloatNode:
-					value[i] = b.Value
+					if fold.decimal {
+						value[i] = decimal.NewFromFloat(b.Value)
+					} else {
+						value[i] = b.Value
+					}
 				case *StringNode:
 					value[i] = b.Value
 				case *BoolNode:
@@ -239,13 +306,32 @@ func (fold *fold) Visit(node *Node) {
 			patch(&ConstantNode{Value: value})
 		}
 
+	case *VariableDeclaratorNode:
+		if literal(n.Value) && !shadowed(n.Expr, n.Name) {
+			Walk(&n.Expr, &inline{name: n.Name, value: n.Value})
+			fold.applied = true
+			*node = n.Expr
+		}
+
 	case *BuiltinNode:
+		if value, ok := fold.builtin(n); ok {
+			if _, isFloat := value.(float64); isFloat && fold.decimal {
+				// FloatNode is compiled to a decimal in the decimal mode.
+				patch(&ConstantNode{Value: value})
+			} else {
+				patch(toLiteral(value))
+			}
+			return
+		}
+		if n.Namespace != "" {
+			return
+		}
 		switch n.Name {
 		case "filter":
 			if len(n.Arguments) != 2 {
 				return
 			}
-			if base, ok := n.Arguments[0].(*BuiltinNode); ok && base.Name == "filter" {
+			if base, ok := n.Arguments[0].(*BuiltinNode); ok && base.Namespace == "" && base.Name == "filter" {
 				patch(&BuiltinNode{
 					Name: "filter",
 					Arguments: []Node{
@@ -262,6 +348,153 @@ func (fold *fold) Visit(node *Node) {
 	}
 }
 
+// builtin calls foldable members of namespaces with literal arguments,
+// and resolves values of constants.
+func (fold *fold) builtin(n *BuiltinNode) (value interface{}, ok bool) {
+	space, found := fold.namespaces.Get(n.Namespace)
+	if !found {
+		return nil, false
+	}
+	member, found := space.Get(n.Name)
+	if !found {
+		return nil, false
+	}
+
+	if c, isConst := member.(builtin.Constant); isConst && n.Arguments == nil {
+		return c.GetValue(), true
+	}
+
+	f, isFoldable := member.(builtin.Foldable)
+	if !isFoldable || !f.Foldable() {
+		return nil, false
+	}
+
+	args := make([]interface{}, len(n.Arguments))
+	for i, arg := range n.Arguments {
+		switch a := arg.(type) {
+		case *NilNode:
+			args[i] = nil
+		case *IntegerNode:
+			args[i] = a.Value
+		case *FloatNode:
+			if fold.decimal {
+				args[i] = decimal.NewFromFloat(a.Value)
+			} else {
+				args[i] = a.Value
+			}
+		case *BoolNode:
+			args[i] = a.Value
+		case *StringNode:
+			args[i] = a.Value
+		case *ConstantNode:
+			args[i] = a.Value
+		default:
+			return nil, false
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprintf("%v", r)
+			// Make message more actual, it's a runtime error, but at compile step.
+			msg = strings.Replace(msg, "runtime error:", "compile error:", 1)
+			fold.err = &file.Error{
+				Location: n.Location(),
+				Code:     runtime.ErrorCode(r),
+				Message:  msg,
+			}
+			value, ok = nil, false
+		}
+	}()
+
+	value, err := f.Run(args...)
+	if err != nil {
+		fold.err = &file.Error{
+			Location: n.Location(),
+			Code:     runtime.ErrorCode(err),
+			Message:  err.Error(),
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// inline replaces usages of the let binding with its literal value.
+type inline struct {
+	name  string
+	value Node
+}
+
+func (i *inline) Visit(node *Node) {
+	if n, ok := (*node).(*IdentifierNode); ok && n.Value == i.name {
+		Patch(node, copyLiteral(i.value))
+	}
+}
+
+func literal(n Node) bool {
+	switch n.(type) {
+	case *NilNode, *IntegerNode, *FloatNode, *BoolNode, *StringNode, *ConstantNode:
+		return true
+	}
+	return false
+}
+
+func copyLiteral(n Node) Node {
+	switch a := n.(type) {
+	case *NilNode:
+		return &NilNode{}
+	case *IntegerNode:
+		return &IntegerNode{Value: a.Value}
+	case *FloatNode:
+		return &FloatNode{Value: a.Value}
+	case *BoolNode:
+		return &BoolNode{Value: a.Value}
+	case *StringNode:
+		return &StringNode{Value: a.Value}
+	case *ConstantNode:
+		return &ConstantNode{Value: a.Value}
+	}
+	panic(fmt.Sprintf("cannot copy %T", n))
+}
+
+// shadowed reports whether the name is redeclared somewhere inside the node.
+// Such bindings are not inlined for simplicity.
+func shadowed(node Node, name string) bool {
+	s := &shadowing{name: name}
+	Walk(&node, s)
+	return s.found
+}
+
+type shadowing struct {
+	name  string
+	found bool
+}
+
+func (s *shadowing) Visit(node *Node) {
+	switch n := (*node).(type) {
+	case *VariableDeclaratorNode:
+		s.found = s.found || n.Name == s.name
+	case *ClosureNode:
+		for _, param := range n.Params {
+			s.found = s.found || param == s.name
+		}
+	}
+}
+
+func toLiteral(value interface{}) Node {
+	switch v := value.(type) {
+	case int:
+		return &IntegerNode{Value: v}
+	case float64:
+		return &FloatNode{Value: v}
+	case bool:
+		return &BoolNode{Value: v}
+	case string:
+		return &StringNode{Value: v}
+	}
+	return &ConstantNode{Value: value}
+}
+
 func toString(n Node) *StringNode {
 	switch a := n.(type) {
 	case *StringNode:
@@ -3,12 +3,19 @@ package optimizer
 import (
 	. "github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/conf"
+	"github.com/antonmedv/expr/namespaces"
 )
 
 func Optimize(node *Node, config *conf.Config) error {
 	Walk(node, &inArray{})
+	space := namespaces.With(nil)
+	decimal := false
+	if config != nil {
+		space = namespaces.With(config.Namespaces)
+		decimal = config.Decimal
+	}
 	for limit := 1000; limit >= 0; limit-- {
-		fold := &fold{}
+		fold := &fold{namespaces: space, decimal: decimal}
 		Walk(node, fold)
 		if fold.err != nil {
 			return fold.err
@@ -7,6 +7,7 @@ import (
 	"github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/checker"
 	"github.com/antonmedv/expr/conf"
+	"github.com/antonmedv/expr/file"
 	"github.com/antonmedv/expr/optimizer"
 	"github.com/antonmedv/expr/parser"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,135 @@ func TestOptimize_constant_folding_with_floats(t *testing.T) {
 	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
 }
 
+func TestOptimize_constant_folding_with_builtins(t *testing.T) {
+	tree, err := parser.Parse(`math.max(1, 2, math.abs(-3)) + math.floor(math.pi)`)
+	require.NoError(t, err)
+
+	_, err = checker.Check(tree, nil)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	expected := &ast.FloatNode{Value: 6.0}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_constant_folding_with_strings(t *testing.T) {
+	tree, err := parser.Parse(`strings.upper(strings.trim(" a ")) + "b"`)
+	require.NoError(t, err)
+
+	_, err = checker.Check(tree, nil)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	expected := &ast.StringNode{Value: "Ab"}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_constant_folding_with_bitwise(t *testing.T) {
+	tree, err := parser.Parse(`(0xF0 | 0x0F) & (1 << 4) xor 7 div 2 + (256 >> 4)`)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	expected := &ast.IntegerNode{Value: 16 ^ (3 + 16)}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_constant_folding_integer_divide_by_zero(t *testing.T) {
+	tree, err := parser.Parse(`1 div 0`)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.Error(t, err)
+	assert.Equal(t, "integer divide by zero", err.(*file.Error).Message)
+}
+
+func TestOptimize_constant_folding_decimal(t *testing.T) {
+	tree, err := parser.Parse(`0.1 + 0.2 + 1 / 2 + (1 + 2)`)
+	require.NoError(t, err)
+
+	config := conf.New(nil)
+	config.Decimal = true
+
+	err = optimizer.Optimize(&tree.Node, config)
+	require.NoError(t, err)
+
+	expected := &ast.BinaryNode{
+		Operator: "+",
+		Left: &ast.BinaryNode{
+			Operator: "+",
+			Left: &ast.BinaryNode{
+				Operator: "+",
+				Left:     &ast.FloatNode{Value: 0.1},
+				Right:    &ast.FloatNode{Value: 0.2},
+			},
+			Right: &ast.BinaryNode{
+				Operator: "/",
+				Left:     &ast.IntegerNode{Value: 1},
+				Right:    &ast.IntegerNode{Value: 2},
+			},
+		},
+		Right: &ast.IntegerNode{Value: 3},
+	}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_inline_let(t *testing.T) {
+	tree, err := parser.Parse(`let x = 2 * 3; let y = x + 1; let z = y * Int; z + x`)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	expected := &ast.VariableDeclaratorNode{
+		Name: "z",
+		Value: &ast.BinaryNode{
+			Operator: "*",
+			Left:     &ast.IntegerNode{Value: 7},
+			Right:    &ast.IdentifierNode{Value: "Int"},
+		},
+		Expr: &ast.BinaryNode{
+			Operator: "+",
+			Left:     &ast.IdentifierNode{Value: "z"},
+			Right:    &ast.IntegerNode{Value: 6},
+		},
+	}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
+func TestOptimize_inline_let_shadowed(t *testing.T) {
+	tree, err := parser.Parse(`let x = 1; map(Array, x => x + 1)`)
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	_, ok := tree.Node.(*ast.VariableDeclaratorNode)
+	assert.True(t, ok)
+}
+
+func TestOptimize_template(t *testing.T) {
+	tree, err := parser.Parse("`a${`b`}c`")
+	require.NoError(t, err)
+
+	err = optimizer.Optimize(&tree.Node, nil)
+	require.NoError(t, err)
+
+	expected := &ast.StringNode{Value: "abc"}
+
+	assert.Equal(t, ast.Dump(expected), ast.Dump(tree.Node))
+}
+
 func TestOptimize_in_array(t *testing.T) {
 	config := conf.New(map[string]int{"v": 0})
 
@@ -17,6 +17,7 @@ func Lex(source *file.Source) ([]Token, error) {
 	l.loc = file.Location{Line: 1, Column: 0}
 	l.prev = l.loc
 	l.startLoc = l.loc
+	l.last = l.loc
 
 	for state := root; state != nil; {
 		state = state(l)
@@ -36,7 +37,9 @@ type lexer struct {
 	width      int           // last rune width
 	startLoc   file.Location // start location
 	prev, loc  file.Location // prev location of end location, end location
+	last       file.Location // location of the end of input
 	err        *file.Error
+	templates  []int // depth of braces inside of each opened ${...} of a template literal
 }
 
 const eof rune = -1
@@ -51,12 +54,16 @@ func (l *lexer) next() rune {
 	l.end += w
 
 	l.prev = l.loc
+	l.loc.Offset += w
 	if r == '\n' {
 		l.loc.Line++
 		l.loc.Column = 0
 	} else {
 		l.loc.Column++
 	}
+	if l.end == len(l.input) {
+		l.last = l.loc
+	}
 
 	return r
 }
@@ -78,7 +85,7 @@ func (l *lexer) emit(t Kind) {
 
 func (l *lexer) emitValue(t Kind, value string) {
 	l.tokens = append(l.tokens, Token{
-		Location: l.startLoc,
+		Location: span(l.startLoc, l.endLoc()),
 		Kind:     t,
 		Value:    value,
 	})
@@ -88,7 +95,7 @@ func (l *lexer) emitValue(t Kind, value string) {
 
 func (l *lexer) emitEOF() {
 	l.tokens = append(l.tokens, Token{
-		Location: l.prev, // Point to previous position for better error messages.
+		Location: span(l.prev, l.last), // Point to previous position for better error messages.
 		Kind:     EOF,
 	})
 	l.start = l.end
@@ -153,13 +160,30 @@ func (l *lexer) acceptWord(word string) bool {
 func (l *lexer) error(format string, args ...interface{}) stateFn {
 	if l.err == nil { // show first error
 		l.err = &file.Error{
-			Location: l.loc,
+			Location: span(l.loc, l.loc),
+			Code:     file.SyntaxError,
 			Message:  fmt.Sprintf(format, args...),
 		}
 	}
 	return nil
 }
 
+// endLoc returns the location of the end position. The end location of
+// input is kept apart, as backup() after eof moves loc to the previous one.
+func (l *lexer) endLoc() file.Location {
+	if l.end == len(l.input) {
+		return l.last
+	}
+	return l.loc
+}
+
+// span returns the location of the token from start up to end.
+func span(start, end file.Location) file.Location {
+	start.EndLine = end.Line
+	start.EndColumn = end.Column
+	return start
+}
+
 func digitVal(ch rune) int {
 	switch {
 	case '0' <= ch && ch <= '9':
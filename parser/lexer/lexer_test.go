@@ -73,6 +73,17 @@ var lexTests = []lexTest{
 			{Kind: EOF},
 		},
 	},
+	{
+		"foo?.bar ?? baz",
+		[]Token{
+			{Kind: Identifier, Value: "foo"},
+			{Kind: Operator, Value: "?."},
+			{Kind: Identifier, Value: "bar"},
+			{Kind: Operator, Value: "??"},
+			{Kind: Identifier, Value: "baz"},
+			{Kind: EOF},
+		},
+	},
 	{
 		"foo ? .bar : .baz",
 		[]Token{
@@ -154,6 +165,169 @@ var lexTests = []lexTest{
 			{Kind: EOF},
 		},
 	},
+	{
+		`[...a] f(b...) .c`,
+		[]Token{
+			{Kind: Bracket, Value: "["},
+			{Kind: Operator, Value: "..."},
+			{Kind: Identifier, Value: "a"},
+			{Kind: Bracket, Value: "]"},
+			{Kind: Identifier, Value: "f"},
+			{Kind: Bracket, Value: "("},
+			{Kind: Identifier, Value: "b"},
+			{Kind: Operator, Value: "..."},
+			{Kind: Bracket, Value: ")"},
+			{Kind: Operator, Value: "."},
+			{Kind: Identifier, Value: "c"},
+			{Kind: EOF},
+		},
+	},
+	{
+		`72h 1h30m 1.5s 500ms 10us 1..5 d"2024-01-31" t'2024-01-31T10:00:00Z' d t`,
+		[]Token{
+			{Kind: Duration, Value: "72h"},
+			{Kind: Duration, Value: "1h30m"},
+			{Kind: Duration, Value: "1.5s"},
+			{Kind: Duration, Value: "500ms"},
+			{Kind: Duration, Value: "10us"},
+			{Kind: Number, Value: "1"},
+			{Kind: Operator, Value: ".."},
+			{Kind: Number, Value: "5"},
+			{Kind: Date, Value: "2024-01-31"},
+			{Kind: DateTime, Value: "2024-01-31T10:00:00Z"},
+			{Kind: Identifier, Value: "d"},
+			{Kind: Identifier, Value: "t"},
+			{Kind: EOF},
+		},
+	},
+	{
+		`(x, i) => x >= i == 1`,
+		[]Token{
+			{Kind: Bracket, Value: "("},
+			{Kind: Identifier, Value: "x"},
+			{Kind: Operator, Value: ","},
+			{Kind: Identifier, Value: "i"},
+			{Kind: Bracket, Value: ")"},
+			{Kind: Operator, Value: "=>"},
+			{Kind: Identifier, Value: "x"},
+			{Kind: Operator, Value: ">="},
+			{Kind: Identifier, Value: "i"},
+			{Kind: Operator, Value: "=="},
+			{Kind: Number, Value: "1"},
+			{Kind: EOF},
+		},
+	},
+	{
+		`let x = 1; x`,
+		[]Token{
+			{Kind: Identifier, Value: "let"},
+			{Kind: Identifier, Value: "x"},
+			{Kind: Operator, Value: "="},
+			{Kind: Number, Value: "1"},
+			{Kind: Operator, Value: ";"},
+			{Kind: Identifier, Value: "x"},
+			{Kind: EOF},
+		},
+	},
+	{
+		"`Hi ${user.Name}, ${map(a, {#})[0]} \\${x}`",
+		[]Token{
+			{Kind: Bracket, Value: "`"},
+			{Kind: Template, Value: "Hi "},
+			{Kind: Bracket, Value: "${"},
+			{Kind: Identifier, Value: "user"},
+			{Kind: Operator, Value: "."},
+			{Kind: Identifier, Value: "Name"},
+			{Kind: Bracket, Value: "}"},
+			{Kind: Template, Value: ", "},
+			{Kind: Bracket, Value: "${"},
+			{Kind: Identifier, Value: "map"},
+			{Kind: Bracket, Value: "("},
+			{Kind: Identifier, Value: "a"},
+			{Kind: Operator, Value: ","},
+			{Kind: Bracket, Value: "{"},
+			{Kind: Operator, Value: "#"},
+			{Kind: Bracket, Value: "}"},
+			{Kind: Bracket, Value: ")"},
+			{Kind: Bracket, Value: "["},
+			{Kind: Number, Value: "0"},
+			{Kind: Bracket, Value: "]"},
+			{Kind: Bracket, Value: "}"},
+			{Kind: Template, Value: " ${x}"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: EOF},
+		},
+	},
+	{
+		"`a ${`b ${c}`}` ``",
+		[]Token{
+			{Kind: Bracket, Value: "`"},
+			{Kind: Template, Value: "a "},
+			{Kind: Bracket, Value: "${"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: Template, Value: "b "},
+			{Kind: Bracket, Value: "${"},
+			{Kind: Identifier, Value: "c"},
+			{Kind: Bracket, Value: "}"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: Bracket, Value: "}"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: Bracket, Value: "`"},
+			{Kind: EOF},
+		},
+	},
+	{
+		"a // comment\n/ b /* multi\nline */ * c //",
+		[]Token{
+			{Kind: Identifier, Value: "a"},
+			{Kind: Operator, Value: "/"},
+			{Kind: Identifier, Value: "b"},
+			{Kind: Operator, Value: "*"},
+			{Kind: Identifier, Value: "c"},
+			{Kind: EOF},
+		},
+	},
+	{
+		`a << 1 >> b & c | d xor e div f < g <= h`,
+		[]Token{
+			{Kind: Identifier, Value: "a"},
+			{Kind: Operator, Value: "<<"},
+			{Kind: Number, Value: "1"},
+			{Kind: Operator, Value: ">>"},
+			{Kind: Identifier, Value: "b"},
+			{Kind: Operator, Value: "&"},
+			{Kind: Identifier, Value: "c"},
+			{Kind: Operator, Value: "|"},
+			{Kind: Identifier, Value: "d"},
+			{Kind: Operator, Value: "xor"},
+			{Kind: Identifier, Value: "e"},
+			{Kind: Operator, Value: "div"},
+			{Kind: Identifier, Value: "f"},
+			{Kind: Operator, Value: "<"},
+			{Kind: Identifier, Value: "g"},
+			{Kind: Operator, Value: "<="},
+			{Kind: Identifier, Value: "h"},
+			{Kind: EOF},
+		},
+	},
+	{
+		`a |> f() || b|>g()`,
+		[]Token{
+			{Kind: Identifier, Value: "a"},
+			{Kind: Operator, Value: "|>"},
+			{Kind: Identifier, Value: "f"},
+			{Kind: Bracket, Value: "("},
+			{Kind: Bracket, Value: ")"},
+			{Kind: Operator, Value: "||"},
+			{Kind: Identifier, Value: "b"},
+			{Kind: Operator, Value: "|>"},
+			{Kind: Identifier, Value: "g"},
+			{Kind: Bracket, Value: "("},
+			{Kind: Bracket, Value: ")"},
+			{Kind: EOF},
+		},
+	},
 	{
 		`$i _0 früh`,
 		[]Token{
@@ -198,13 +372,42 @@ func TestLex_location(t *testing.T) {
 	tokens, err := Lex(source)
 	require.NoError(t, err)
 	require.Equal(t, []Token{
-		{Location: file.Location{Line: 1, Column: 0}, Kind: Number, Value: "1"},
-		{Location: file.Location{Line: 1, Column: 1}, Kind: Operator, Value: ".."},
-		{Location: file.Location{Line: 1, Column: 3}, Kind: Number, Value: "2"},
-		{Location: file.Location{Line: 1, Column: 5}, Kind: Number, Value: "3"},
-		{Location: file.Location{Line: 1, Column: 6}, Kind: Operator, Value: ".."},
-		{Location: file.Location{Line: 1, Column: 8}, Kind: Number, Value: "4"},
-		{Location: file.Location{Line: 1, Column: 8}, Kind: EOF, Value: ""},
+		{Location: file.Location{Line: 1, Column: 0, Offset: 0, EndLine: 1, EndColumn: 1}, Kind: Number, Value: "1"},
+		{Location: file.Location{Line: 1, Column: 1, Offset: 1, EndLine: 1, EndColumn: 3}, Kind: Operator, Value: ".."},
+		{Location: file.Location{Line: 1, Column: 3, Offset: 3, EndLine: 1, EndColumn: 4}, Kind: Number, Value: "2"},
+		{Location: file.Location{Line: 1, Column: 5, Offset: 5, EndLine: 1, EndColumn: 6}, Kind: Number, Value: "3"},
+		{Location: file.Location{Line: 1, Column: 6, Offset: 6, EndLine: 1, EndColumn: 8}, Kind: Operator, Value: ".."},
+		{Location: file.Location{Line: 1, Column: 8, Offset: 8, EndLine: 1, EndColumn: 9}, Kind: Number, Value: "4"},
+		{Location: file.Location{Line: 1, Column: 8, Offset: 8, EndLine: 1, EndColumn: 9}, Kind: EOF, Value: ""},
+	}, tokens)
+}
+
+func TestLex_location_comments(t *testing.T) {
+	source := file.NewSource("/* a\n * b */ 1 +\n// c\n  2")
+	tokens, err := Lex(source)
+	require.NoError(t, err)
+	require.Equal(t, []Token{
+		{Location: file.Location{Line: 2, Column: 8, Offset: 13, EndLine: 2, EndColumn: 9}, Kind: Number, Value: "1"},
+		{Location: file.Location{Line: 2, Column: 10, Offset: 15, EndLine: 2, EndColumn: 11}, Kind: Operator, Value: "+"},
+		{Location: file.Location{Line: 4, Column: 2, Offset: 24, EndLine: 4, EndColumn: 3}, Kind: Number, Value: "2"},
+		{Location: file.Location{Line: 4, Column: 2, Offset: 24, EndLine: 4, EndColumn: 3}, Kind: EOF, Value: ""},
+	}, tokens)
+}
+
+func TestLex_location_offset(t *testing.T) {
+	source := file.NewSource("früh +\n`a${b}`")
+	tokens, err := Lex(source)
+	require.NoError(t, err)
+	require.Equal(t, []Token{
+		{Location: file.Location{Line: 1, Column: 0, Offset: 0, EndLine: 1, EndColumn: 4}, Kind: Identifier, Value: "früh"},
+		{Location: file.Location{Line: 1, Column: 5, Offset: 6, EndLine: 1, EndColumn: 6}, Kind: Operator, Value: "+"},
+		{Location: file.Location{Line: 2, Column: 0, Offset: 8, EndLine: 2, EndColumn: 1}, Kind: Bracket, Value: "`"},
+		{Location: file.Location{Line: 2, Column: 1, Offset: 9, EndLine: 2, EndColumn: 2}, Kind: Template, Value: "a"},
+		{Location: file.Location{Line: 2, Column: 2, Offset: 10, EndLine: 2, EndColumn: 4}, Kind: Bracket, Value: "${"},
+		{Location: file.Location{Line: 2, Column: 4, Offset: 12, EndLine: 2, EndColumn: 5}, Kind: Identifier, Value: "b"},
+		{Location: file.Location{Line: 2, Column: 5, Offset: 13, EndLine: 2, EndColumn: 6}, Kind: Bracket, Value: "}"},
+		{Location: file.Location{Line: 2, Column: 6, Offset: 14, EndLine: 2, EndColumn: 7}, Kind: Bracket, Value: "`"},
+		{Location: file.Location{Line: 2, Column: 6, Offset: 14, EndLine: 2, EndColumn: 7}, Kind: EOF, Value: ""},
 	}, tokens)
 }
 
@@ -222,6 +425,21 @@ literal not terminated (1:10)
 früh ♥︎
 unrecognized character: U+2665 '♥' (1:7)
  | früh ♥︎
+
+1hour
+bad duration syntax: "1ho" (1:4)
+ | 1hour
+ | ...^
+
+1h30
+bad duration syntax: "1h30" (1:4)
+ | 1h30
+ | ...^
+
+1 /* 2
+comment not terminated (1:7)
+ | 1 /* 2
+ | ......^
 `
 
 func TestLex_error(t *testing.T) {
@@ -242,3 +460,9 @@ func TestLex_error(t *testing.T) {
 		assert.Equal(t, input[1], err.Error(), input[0])
 	}
 }
+
+func TestLex_template_error(t *testing.T) {
+	_, err := Lex(file.NewSource("`Hello ${name}"))
+	require.Error(t, err)
+	assert.Equal(t, "literal not terminated (1:15)\n | `Hello ${name}\n | ..............^", err.Error())
+}
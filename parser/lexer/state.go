@@ -21,16 +21,45 @@ func root(l *lexer) stateFn {
 			l.error("%v", err)
 		}
 		l.emitValue(String, str)
+	case r == '`':
+		l.emit(Bracket)
+		return template
 	case '0' <= r && r <= '9':
 		l.backup()
 		return number
 	case r == '?':
 		return questionMark
+	case r == '/' && l.peek() == '/':
+		return lineComment
+	case r == '/' && l.peek() == '*':
+		return blockComment
 	case strings.ContainsRune("([{", r):
+		if r == '{' && len(l.templates) > 0 {
+			l.templates[len(l.templates)-1]++
+		}
 		l.emit(Bracket)
 	case strings.ContainsRune(")]}", r):
+		if r == '}' && len(l.templates) > 0 {
+			depth := &l.templates[len(l.templates)-1]
+			if *depth == 0 {
+				// End of ${...}, continue with the rest of the template.
+				l.templates = l.templates[:len(l.templates)-1]
+				l.emit(Bracket)
+				return template
+			}
+			*depth--
+		}
 		l.emit(Bracket)
-	case strings.ContainsRune("#,?:%+-/^", r): // single rune operator
+	case strings.ContainsRune("#,;?:%+-/^", r): // single rune operator
+		l.emit(Operator)
+	case r == '=' && l.peek() == '>': // arrow of closure
+		l.next()
+		l.emit(Operator)
+	case r == '<' && l.peek() == '<' || r == '>' && l.peek() == '>': // shift
+		l.next()
+		l.emit(Operator)
+	case r == '|' && l.peek() == '>': // pipe
+		l.next()
 		l.emit(Operator)
 	case strings.ContainsRune("&|!=*<>", r): // possible double rune operator
 		l.accept("&|=*")
@@ -47,10 +76,53 @@ func root(l *lexer) stateFn {
 	return root
 }
 
+// template scans the text of a template literal `...${expr}...` up to
+// the closing backtick or the start of an interpolation.
+func template(l *lexer) stateFn {
+	for {
+		end, loc := l.end, l.loc
+		switch r := l.next(); {
+		case r == eof:
+			return l.error("literal not terminated")
+		case r == '\\':
+			l.next()
+		case r == '`' || r == '$' && l.peek() == '{':
+			if end > l.start {
+				str, err := unescape(`"` + l.input[l.start:end] + `"`)
+				if err != nil {
+					return l.error("%v", err)
+				}
+				l.tokens = append(l.tokens, Token{
+					Location: span(l.startLoc, loc),
+					Kind:     Template,
+					Value:    str,
+				})
+				l.start, l.startLoc = end, loc
+			}
+			if r == '`' {
+				l.emit(Bracket)
+				return root
+			}
+			l.next()
+			l.emit(Bracket)
+			l.templates = append(l.templates, 0)
+			return root
+		}
+	}
+}
+
 func number(l *lexer) stateFn {
 	if !l.scanNumber() {
 		return l.error("bad number syntax: %q", l.word())
 	}
+	if l.acceptDurationUnit() {
+		return duration
+	}
+	// Next thing mustn't be alphanumeric.
+	if IsAlphaNumeric(l.peek()) {
+		l.next()
+		return l.error("bad number syntax: %q", l.word())
+	}
 	l.emit(Number)
 	return root
 }
@@ -85,12 +157,41 @@ func (l *lexer) scanNumber() bool {
 		l.accept("+-")
 		l.acceptRun(digits)
 	}
-	// Next thing mustn't be alphanumeric.
-	if IsAlphaNumeric(l.peek()) {
+	return true
+}
+
+// duration scans the rest of a duration literal like 1h30m, after
+// the first number and its unit.
+func duration(l *lexer) stateFn {
+	for l.accept("0123456789") {
+		l.acceptRun("0123456789")
+		if l.accept(".") {
+			l.acceptRun("0123456789")
+		}
+		if !l.acceptDurationUnit() {
+			l.next()
+			return l.error("bad duration syntax: %q", l.word())
+		}
+	}
+	if IsAlphaNumeric(l.peek()) || l.peek() == '.' {
 		l.next()
-		return false
+		return l.error("bad duration syntax: %q", l.word())
 	}
-	return true
+	l.emit(Duration)
+	return root
+}
+
+func (l *lexer) acceptDurationUnit() bool {
+	switch {
+	case l.accept("hs"):
+		return true
+	case l.accept("m"):
+		l.accept("s")
+		return true
+	case l.accept("nuµ"):
+		return l.accept("s")
+	}
+	return false
 }
 
 func dot(l *lexer) stateFn {
@@ -99,7 +200,9 @@ func dot(l *lexer) stateFn {
 		l.backup()
 		return number
 	}
-	l.accept(".")
+	if l.accept(".") {
+		l.accept(".")
+	}
 	l.emit(Operator)
 	return root
 }
@@ -115,8 +218,13 @@ loop:
 			switch l.word() {
 			case "not":
 				return not
-			case "in", "or", "and", "matches", "contains", "startsWith", "endsWith":
+			case "in", "or", "and", "xor", "div", "matches", "contains", "startsWith", "endsWith":
 				l.emit(Operator)
+			case "d", "t":
+				if r := l.peek(); r == '"' || r == '\'' {
+					return dateTime
+				}
+				l.emit(Identifier)
 			default:
 				l.emit(Identifier)
 			}
@@ -126,6 +234,22 @@ loop:
 	return root
 }
 
+// dateTime scans a quoted date literal d"2006-01-02" or time literal
+// t"2006-01-02T15:04:05Z" after its prefix.
+func dateTime(l *lexer) stateFn {
+	kind := Date
+	if l.word() == "t" {
+		kind = DateTime
+	}
+	l.scanString(l.next())
+	str, err := unescape(l.word()[1:])
+	if err != nil {
+		return l.error("%v", err)
+	}
+	l.emitValue(kind, str)
+	return root
+}
+
 func not(l *lexer) stateFn {
 	l.emit(Operator)
 
@@ -153,8 +277,33 @@ func not(l *lexer) stateFn {
 	return root
 }
 
+// lineComment skips a // comment up to the end of the line.
+func lineComment(l *lexer) stateFn {
+	for r := l.next(); r != '\n' && r != eof; r = l.next() {
+	}
+	l.ignore()
+	return root
+}
+
+// blockComment skips a /* */ comment, which may span multiple lines.
+func blockComment(l *lexer) stateFn {
+	l.next() // read '*' after '/'
+	for {
+		switch l.next() {
+		case eof:
+			return l.error("comment not terminated")
+		case '*':
+			if l.peek() == '/' {
+				l.next()
+				l.ignore()
+				return root
+			}
+		}
+	}
+}
+
 func questionMark(l *lexer) stateFn {
-	l.accept(".")
+	l.accept(".?")
 	l.emit(Operator)
 	return root
 }
@@ -12,8 +12,12 @@ const (
 	Identifier Kind = "Identifier"
 	Number     Kind = "Number"
 	String     Kind = "String"
+	Template   Kind = "Template"
 	Operator   Kind = "Operator"
 	Bracket    Kind = "Bracket"
+	Duration   Kind = "Duration"
+	Date       Kind = "Date"
+	DateTime   Kind = "DateTime"
 	EOF        Kind = "EOF"
 )
 
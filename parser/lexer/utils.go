@@ -115,6 +115,8 @@ func unescapeChar(s string) (value rune, multibyte bool, tail string, err error)
 		value = '`'
 	case '?':
 		value = '?'
+	case '$':
+		value = '$'
 
 	// 4. Unicode escape sequences, reproduced from `strconv/quote.go`
 	case 'x', 'X', 'u', 'U':
@@ -0,0 +1,53 @@
+package operator
+
+type Associativity int
+
+const (
+	Left Associativity = iota + 1
+	Right
+)
+
+type Operator struct {
+	Precedence    int
+	Associativity Associativity
+}
+
+var Unary = map[string]Operator{
+	"not": {50, Left},
+	"!":   {50, Left},
+	"-":   {90, Left},
+	"+":   {90, Left},
+}
+
+var Binary = map[string]Operator{
+	"??":         {5, Left},
+	"or":         {10, Left},
+	"||":         {10, Left},
+	"and":        {15, Left},
+	"&&":         {15, Left},
+	"==":         {20, Left},
+	"!=":         {20, Left},
+	"<":          {20, Left},
+	">":          {20, Left},
+	">=":         {20, Left},
+	"<=":         {20, Left},
+	"in":         {20, Left},
+	"matches":    {20, Left},
+	"contains":   {20, Left},
+	"startsWith": {20, Left},
+	"endsWith":   {20, Left},
+	"|":          {21, Left},
+	"xor":        {22, Left},
+	"&":          {23, Left},
+	"..":         {25, Left},
+	"<<":         {27, Left},
+	">>":         {27, Left},
+	"+":          {30, Left},
+	"-":          {30, Left},
+	"*":          {60, Left},
+	"/":          {60, Left},
+	"div":        {60, Left},
+	"%":          {60, Left},
+	"**":         {100, Right},
+	"^":          {100, Right},
+}
@@ -4,28 +4,19 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	. "github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/builtin"
+	"github.com/antonmedv/expr/conf"
 	"github.com/antonmedv/expr/file"
 	"github.com/antonmedv/expr/namespaces"
 	. "github.com/antonmedv/expr/parser/lexer"
+	"github.com/antonmedv/expr/parser/operator"
 	"github.com/antonmedv/expr/util/typing"
 )
 
-type associativity int
-
-const (
-	left associativity = iota + 1
-	right
-)
-
-type operator struct {
-	precedence    int
-	associativity associativity
-}
-
 type arg int
 
 const (
@@ -38,45 +29,14 @@ var (
 	arg_expression_and_closure = []arg{expression, closure}
 )
 
-var unaryOperators = map[string]operator{
-	"not": {50, left},
-	"!":   {50, left},
-	"-":   {90, left},
-	"+":   {90, left},
-}
-
-var binaryOperators = map[string]operator{
-	"or":         {10, left},
-	"||":         {10, left},
-	"and":        {15, left},
-	"&&":         {15, left},
-	"==":         {20, left},
-	"!=":         {20, left},
-	"<":          {20, left},
-	">":          {20, left},
-	">=":         {20, left},
-	"<=":         {20, left},
-	"in":         {20, left},
-	"matches":    {20, left},
-	"contains":   {20, left},
-	"startsWith": {20, left},
-	"endsWith":   {20, left},
-	"..":         {25, left},
-	"+":          {30, left},
-	"-":          {30, left},
-	"*":          {60, left},
-	"/":          {60, left},
-	"%":          {60, left},
-	"**":         {100, right},
-	"^":          {100, right},
-}
-
 type parser struct {
-	tokens  []Token
-	current Token
-	pos     int
-	err     *file.Error
-	depth   int // closure call depth
+	tokens     []Token
+	current    Token
+	pos        int
+	err        *file.Error // the error the parser is recovering from
+	errors     file.Errors
+	depth      int // closure call depth
+	namespaces namespaces.Table
 }
 
 type Tree struct {
@@ -84,7 +44,23 @@ type Tree struct {
 	Source *file.Source
 }
 
+// NewTree returns the tree of the node, which is built without parsing, e.g.
+// unmarshalled with ast.Unmarshal. It has no source, so errors have locations
+// of the nodes, but no snippets.
+func NewTree(node Node) *Tree {
+	return &Tree{
+		Node:   node,
+		Source: file.NewSource(""),
+	}
+}
+
 func Parse(input string) (*Tree, error) {
+	return ParseWithConfig(input, nil)
+}
+
+// ParseWithConfig parses input using builtin namespaces registered in config
+// in addition to the default ones.
+func ParseWithConfig(input string, config *conf.Config) (*Tree, error) {
 	source := file.NewSource(input)
 
 	tokens, err := Lex(source)
@@ -96,6 +72,19 @@ func Parse(input string) (*Tree, error) {
 		tokens:  tokens,
 		current: tokens[0],
 	}
+	if config != nil {
+		p.namespaces = namespaces.With(config.Namespaces)
+		// Variables of the environment take precedence over the default
+		// namespaces, so expressions using a variable named like a namespace
+		// (e.g. time) still work.
+		for name := range config.Types {
+			if _, ok := config.Namespaces[name]; !ok {
+				delete(p.namespaces, name)
+			}
+		}
+	} else {
+		p.namespaces = namespaces.With(nil)
+	}
 
 	node := p.parseExpression(0)
 
@@ -103,8 +92,8 @@ func Parse(input string) (*Tree, error) {
 		p.error("unexpected token %v", p.current)
 	}
 
-	if p.err != nil {
-		return nil, p.err.Bind(source)
+	if len(p.errors) > 0 {
+		return nil, p.errors.Bind(source).Err()
 	}
 
 	return &Tree{
@@ -114,14 +103,54 @@ func Parse(input string) (*Tree, error) {
 }
 
 func (p *parser) error(format string, args ...interface{}) {
-	if p.err == nil { // show first error
+	p.errorCode(file.SyntaxError, format, args...)
+}
+
+func (p *parser) errorCode(code file.ErrorCode, format string, args ...interface{}) {
+	if p.err == nil { // errors after the first one are caused by it, until recovered
 		p.err = &file.Error{
 			Location: p.current.Location,
+			Code:     code,
 			Message:  fmt.Sprintf(format, args...),
 		}
+		p.errors = append(p.errors, p.err)
+	}
+}
+
+// recover skips tokens of the broken element of the list, which starts with
+// the bracket at the open position, until the separator or the closing bracket
+// of the list. So the rest of the list is parsed and its errors are reported.
+func (p *parser) recover(open int, separator, closing string) {
+	if p.err == nil {
+		return
+	}
+	depth := 0
+	for i := open + 1; i < len(p.tokens); i++ {
+		token := p.tokens[i]
+		if token.Is(Bracket, "(", "[", "{", "${") {
+			depth++
+		} else if token.Is(Bracket, ")", "]", "}") {
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if i >= p.pos && token.Is(Bracket, closing) {
+				p.resume(i)
+			}
+			return
+		} else if depth == 0 && i >= p.pos && token.Is(Operator, separator) {
+			p.resume(i)
+			return
+		}
 	}
 }
 
+func (p *parser) resume(pos int) {
+	p.pos = pos
+	p.current = p.tokens[pos]
+	p.err = nil
+}
+
 func (p *parser) next() {
 	p.pos++
 	if p.pos >= len(p.tokens) {
@@ -131,6 +160,13 @@ func (p *parser) next() {
 	p.current = p.tokens[p.pos]
 }
 
+func (p *parser) peek() Token {
+	if p.pos+1 < len(p.tokens) {
+		return p.tokens[p.pos+1]
+	}
+	return p.tokens[len(p.tokens)-1] // EOF
+}
+
 func (p *parser) expect(kind Kind, values ...string) {
 	if p.current.Is(kind, values...) {
 		p.next()
@@ -142,6 +178,10 @@ func (p *parser) expect(kind Kind, values ...string) {
 // parse functions
 
 func (p *parser) parseExpression(precedence int) Node {
+	if precedence == 0 && p.current.Is(Identifier, "let") && p.peek().Is(Identifier) {
+		return p.parseVariableDeclaration()
+	}
+
 	nodeLeft := p.parsePrimary()
 
 	token := p.current
@@ -156,15 +196,25 @@ func (p *parser) parseExpression(precedence int) Node {
 			token = p.current
 		}
 
-		if op, ok := binaryOperators[token.Value]; ok {
-			if op.precedence >= precedence {
+		if token.Is(Operator, "|>") && !negate {
+			if precedence > 0 {
+				break
+			}
+			p.next()
+			nodeLeft = p.parsePipe(nodeLeft)
+			token = p.current
+			continue
+		}
+
+		if op, ok := operator.Binary[token.Value]; ok {
+			if op.Precedence >= precedence {
 				p.next()
 
 				var nodeRight Node
-				if op.associativity == left {
-					nodeRight = p.parseExpression(op.precedence + 1)
+				if op.Associativity == operator.Left {
+					nodeRight = p.parseExpression(op.Precedence + 1)
 				} else {
-					nodeRight = p.parseExpression(op.precedence)
+					nodeRight = p.parseExpression(op.Precedence)
 				}
 
 				nodeLeft = &BinaryNode{
@@ -196,13 +246,151 @@ func (p *parser) parseExpression(precedence int) Node {
 	return nodeLeft
 }
 
+// parsePipe parses the right side of "|>" operator: a call of builtin or
+// function, which gets the left side as the first argument.
+func (p *parser) parsePipe(left Node) Node {
+	token := p.current
+	p.expect(Identifier)
+
+	var node Node
+	if space, ok := p.namespaces.Get(token.Value); ok && p.current.Is(Operator, ".") {
+		p.next()
+		name := p.current
+		p.expect(Identifier)
+		member, ok := space.Get(name.Value)
+		if !ok {
+			p.errorCode(file.UnknownIdentifier, "%s does not exist in builtin namespace %s", name.Value, token.Value)
+			return left
+		}
+		node = &BuiltinNode{
+			Namespace: token.Value,
+			Name:      name.Value,
+			Arguments: p.parsePipeArgumentsBuiltin(token.Value+"."+name.Value, member, left),
+		}
+	} else if b, ok := namespaces.Stdlib.Get(token.Value); ok {
+		node = &BuiltinNode{
+			Name:      token.Value,
+			Arguments: p.parsePipeArgumentsBuiltin(token.Value, b, left),
+		}
+	} else {
+		callee := &IdentifierNode{Value: token.Value}
+		callee.SetLocation(token.Location)
+		node = &CallNode{
+			Callee:    callee,
+			Arguments: append([]Node{left}, p.parseArguments()...),
+		}
+	}
+	node.SetLocation(token.Location)
+
+	return p.parsePostfixExpression(node)
+}
+
+func (p *parser) parsePipeArgumentsBuiltin(name string, member builtin.Member, left Node) []Node {
+	if function, ok := member.(builtin.Function); !ok || !member.Callable() || len(function.Arguments()) == 0 {
+		p.error("cannot pipe into %v", name)
+		return nil
+	}
+	return p.parseArgumentsBuiltin(member, left)
+}
+
+func (p *parser) parseVariableDeclaration() Node {
+	token := p.current
+	p.expect(Identifier, "let")
+	name := p.parseName("variable")
+	p.expect(Operator, "=")
+	value := p.parseExpression(0)
+	p.expect(Operator, ";")
+	node := &VariableDeclaratorNode{
+		Name:  name,
+		Value: value,
+		Expr:  p.parseExpression(0),
+	}
+	node.SetLocation(token.Location)
+	return node
+}
+
+func (p *parser) parseSwitchExpression() Node {
+	token := p.current
+	p.expect(Identifier, "switch")
+	node := &SwitchNode{Node: p.parseExpression(0)}
+	node.SetLocation(token.Location)
+
+	open := p.pos
+	p.expect(Bracket, "{")
+	for !p.current.Is(Bracket, "}") && p.err == nil {
+		if len(node.Cases) > 0 || node.Default != nil {
+			p.expect(Operator, ";")
+			if p.current.Is(Bracket, "}") {
+				break
+			}
+		}
+
+		if p.current.Is(Identifier, "default") {
+			if node.Default != nil {
+				p.error("switch has more than one default case")
+			}
+			p.next()
+			p.expect(Operator, "=>")
+			node.Default = p.parseExpression(0)
+			p.recover(open, ";", "}")
+			continue
+		}
+
+		if node.Default != nil {
+			p.error("default case must be the last one in switch")
+		}
+
+		c := &CaseNode{}
+		c.SetLocation(p.current.Location)
+		p.expect(Identifier, "case")
+		for {
+			c.Conditions = append(c.Conditions, p.parseCaseCondition())
+			if !p.current.Is(Operator, ",") || p.err != nil {
+				break
+			}
+			p.next()
+		}
+		p.expect(Operator, "=>")
+		c.Expr = p.parseExpression(0)
+		node.Cases = append(node.Cases, c)
+		p.recover(open, ";", "}")
+	}
+	p.expect(Bracket, "}")
+
+	if len(node.Cases) == 0 && node.Default == nil {
+		p.error("switch must have at least one case")
+	}
+	return node
+}
+
+// parseCaseCondition parses a value of the case and compares the switch
+// subject with it: a value for equality, a range 1..5 for membership or
+// matches "regexp" for the match.
+func (p *parser) parseCaseCondition() Node {
+	token := p.current
+	subject := &IdentifierNode{Value: SwitchSubject}
+	subject.SetLocation(token.Location)
+
+	node := &BinaryNode{Operator: "==", Left: subject}
+	if token.Is(Operator, "matches") {
+		p.next()
+		node.Operator = "matches"
+	}
+	node.Right = p.parseExpression(0)
+	if rng, ok := node.Right.(*BinaryNode); ok && rng.Operator == ".." && node.Operator == "==" {
+		node.Operator = "in"
+	}
+	node.SetLocation(token.Location)
+	return node
+}
+
 func (p *parser) parsePrimary() Node {
 	token := p.current
 
 	if token.Is(Operator) {
-		if op, ok := unaryOperators[token.Value]; ok {
+		if op, ok := operator.Unary[token.Value]; ok {
 			p.next()
-			expr := p.parseExpression(op.precedence)
+			expr := p.parseExpression(op.Precedence)
 			node := &UnaryNode{
 				Operator: token.Value,
 				Node:     expr,
@@ -219,12 +407,23 @@ func (p *parser) parsePrimary() Node {
 		return p.parsePostfixExpression(expr)
 	}
 
+	if token.Is(Identifier, "switch") {
+		// Otherwise, switch is an ordinary identifier, e.g. switch == 1.
+		if next := p.peek(); next.Is(Operator, "#") || !next.Is(Operator) && !next.Is(EOF) && !next.Is(Bracket, ")", "]", "}") {
+			return p.parsePostfixExpression(p.parseSwitchExpression())
+		}
+	}
+
 	if p.depth > 0 {
 		if token.Is(Operator, "#") || token.Is(Operator, ".") {
+			node := &PointerNode{}
 			if token.Is(Operator, "#") {
 				p.next()
+				if p.current.Is(Identifier, "acc") {
+					node.Name = p.current.Value
+					p.next()
+				}
 			}
-			node := &PointerNode{}
 			node.SetLocation(token.Location)
 			return p.parsePostfixExpression(node)
 		}
@@ -298,14 +497,14 @@ func (p *parser) parsePrimaryExpression() Node {
 					p.next()
 
 					// TODO: names of builtin namespaces are now blocked for everything else!
-					if namespace, ok := namespaces.Get(b.Namespace); ok {
+					if namespace, ok := p.namespaces.Get(b.Namespace); ok {
 						if callee, ok := namespace.Get(b.Name); ok {
 							b.Arguments = p.parseArgumentsBuiltin(callee)
 						} else {
-							p.error("%s does not exist in builtin namespace %s", b.Name, b.Namespace)
+							p.errorCode(file.UnknownIdentifier, "%s does not exist in builtin namespace %s", b.Name, b.Namespace)
 						}
 					} else {
-						p.error("builtin namespace %s does not exist (member %s mentioned)", b.Namespace, b.Name)
+						p.errorCode(file.UnknownIdentifier, "builtin namespace %s does not exist (member %s mentioned)", b.Namespace, b.Name)
 					}
 				}
 			}
@@ -346,11 +545,37 @@ func (p *parser) parsePrimaryExpression() Node {
 		node.SetLocation(token.Location)
 		return node
 
+	case Duration:
+		duration, err := time.ParseDuration(strings.Replace(token.Value, "_", "", -1))
+		if err != nil {
+			p.error("invalid duration literal: %v", err)
+		}
+		p.next()
+		node := &ConstantNode{Value: duration}
+		node.SetLocation(token.Location)
+		return node
+
+	case Date, DateTime:
+		layout := "2006-01-02"
+		if token.Kind == DateTime {
+			layout = time.RFC3339Nano
+		}
+		date, err := time.Parse(layout, token.Value)
+		if err != nil {
+			p.error("invalid date literal: %v", err)
+		}
+		p.next()
+		node := &ConstantNode{Value: date}
+		node.SetLocation(token.Location)
+		return node
+
 	default:
 		if token.Is(Bracket, "[") {
 			node = p.parseArrayExpression(token)
 		} else if token.Is(Bracket, "{") {
 			node = p.parseMapExpression(token)
+		} else if token.Is(Bracket, "`") {
+			node = p.parseTemplateExpression(token)
 		} else {
 			p.error("unexpected token %v", token)
 		}
@@ -365,8 +590,9 @@ func (p *parser) parseIdentifierExpression(token Token) Node {
 	if p.current.Is(Bracket, "(") {
 		// top level call (could be builtin from standard namespace)
 		node = p.parseTopLevelCall(token)
-	} else if _, ok := namespaces.Get(token.Value); ok {
-		// builtin outside standard namespace
+	} else if _, ok := p.namespaces.Get(token.Value); ok && p.current.Is(Operator, ".") {
+		// builtin outside standard namespace, otherwise a variable named
+		// like a namespace
 		node = &BuiltinNode{Namespace: token.Value}
 	} else {
 		// arbitrary identifier
@@ -404,6 +630,9 @@ func (p *parser) parseTopLevelCall(token Token) Node {
 
 func (p *parser) parseClosure() Node {
 	token := p.current
+	if token.Is(Identifier) || token.Is(Bracket, "(") {
+		return p.parseArrowClosure()
+	}
 	p.expect(Bracket, "{")
 
 	p.depth++
@@ -418,9 +647,53 @@ func (p *parser) parseClosure() Node {
 	return closure
 }
 
+// parseArrowClosure parses closures with named params: x => ... or (x, i) => ...
+func (p *parser) parseArrowClosure() Node {
+	token := p.current
+	params := make([]string, 0, 2)
+
+	if token.Is(Identifier) {
+		params = append(params, p.parseName("closure param"))
+	} else {
+		p.expect(Bracket, "(")
+		for !p.current.Is(Bracket, ")") && p.err == nil {
+			if len(params) > 0 {
+				p.expect(Operator, ",")
+			}
+			params = append(params, p.parseName("closure param"))
+		}
+		p.expect(Bracket, ")")
+	}
+	if len(params) == 0 || len(params) > 2 {
+		p.error("closure should have element and optional index params (got %v)", len(params))
+	}
+	p.expect(Operator, "=>")
+
+	p.depth++
+	node := p.parseExpression(0)
+	p.depth--
+
+	closure := &ClosureNode{
+		Params: params,
+		Node:   node,
+	}
+	closure.SetLocation(token.Location)
+	return closure
+}
+
+func (p *parser) parseName(what string) string {
+	token := p.current
+	if _, ok := p.namespaces.Get(token.Value); ok && token.Is(Identifier) {
+		p.error("cannot use builtin namespace %v as %v", token.Value, what)
+	}
+	p.expect(Identifier)
+	return token.Value
+}
+
 func (p *parser) parseArrayExpression(token Token) Node {
 	nodes := make([]Node, 0)
 
+	open := p.pos
 	p.expect(Bracket, "[")
 	for !p.current.Is(Bracket, "]") && p.err == nil {
 		if len(nodes) > 0 {
@@ -429,8 +702,14 @@ func (p *parser) parseArrayExpression(token Token) Node {
 				goto end
 			}
 		}
-		node := p.parseExpression(0)
+		var node Node
+		if p.current.Is(Operator, "...") {
+			node = p.parseSpread()
+		} else {
+			node = p.parseExpression(0)
+		}
 		nodes = append(nodes, node)
+		p.recover(open, ",", "]")
 	}
 end:
 	p.expect(Bracket, "]")
@@ -441,6 +720,7 @@ end:
 }
 
 func (p *parser) parseMapExpression(token Token) Node {
+	open := p.pos
 	p.expect(Bracket, "{")
 
 	nodes := make([]Node, 0)
@@ -455,6 +735,12 @@ func (p *parser) parseMapExpression(token Token) Node {
 			}
 		}
 
+		if p.current.Is(Operator, "...") {
+			nodes = append(nodes, p.parseSpread())
+			p.recover(open, ",", "}")
+			continue
+		}
+
 		var key Node
 		// Map key can be one of:
 		//  * number
@@ -477,6 +763,7 @@ func (p *parser) parseMapExpression(token Token) Node {
 		pair := &PairNode{Key: key, Value: node}
 		pair.SetLocation(token.Location)
 		nodes = append(nodes, pair)
+		p.recover(open, ",", "}")
 	}
 
 end:
@@ -487,10 +774,60 @@ end:
 	return node
 }
 
+func (p *parser) parseTemplateExpression(token Token) Node {
+	p.expect(Bracket, "`")
+
+	parts := make([]Node, 0)
+	for !p.current.Is(Bracket, "`") && p.err == nil {
+		if p.current.Is(Template) {
+			part := &StringNode{Value: p.current.Value}
+			part.SetLocation(p.current.Location)
+			parts = append(parts, part)
+			p.next()
+		} else if p.current.Is(Bracket, "${") {
+			p.next()
+			parts = append(parts, p.parseExpression(0))
+			p.expect(Bracket, "}") // "an opened interpolation is not properly closed"
+		} else {
+			p.error("unexpected token %v", p.current)
+		}
+	}
+	p.expect(Bracket, "`")
+
+	node := &TemplateNode{Parts: parts}
+	node.SetLocation(token.Location)
+	return node
+}
+
 func (p *parser) parsePostfixExpression(node Node) Node {
 	postfixToken := p.current
 	for (postfixToken.Is(Operator) || postfixToken.Is(Bracket)) && p.err == nil {
-		if postfixToken.Value == "." || postfixToken.Value == "?." {
+		if postfixToken.Value == "?." && (p.peek().Is(Bracket, "[") || p.peek().Is(Bracket, "(")) {
+			p.next()
+
+			if chainNode, isChain := node.(*ChainNode); isChain {
+				node = chainNode.Node
+			}
+
+			if p.current.Is(Bracket, "(") {
+				node = &CallNode{
+					Callee:    node,
+					Arguments: p.parseArguments(),
+					Optional:  true,
+				}
+			} else {
+				p.next()
+				node = &MemberNode{
+					Node:     node,
+					Property: p.parseExpression(0),
+					Optional: true,
+				}
+				p.expect(Bracket, "]")
+			}
+			node.SetLocation(postfixToken.Location)
+			node = &ChainNode{Node: node}
+
+		} else if postfixToken.Value == "." || postfixToken.Value == "?." {
 			p.next()
 
 			propertyToken := p.current
@@ -535,51 +872,45 @@ func (p *parser) parsePostfixExpression(node Node) Node {
 
 		} else if postfixToken.Value == "[" {
 			p.next()
-			var from, to Node
+			var from, to, step Node
+
+			if !p.current.Is(Operator, ":") { // slice without from [:1]
+				from = p.parseExpression(0)
+			}
 
-			if p.current.Is(Operator, ":") { // slice without from [:1]
+			if p.current.Is(Operator, ":") {
 				p.next()
 
-				if !p.current.Is(Bracket, "]") { // slice without from and to [:]
+				if !p.current.Is(Bracket, "]") && !p.current.Is(Operator, ":") { // slice without to [1:]
 					to = p.parseExpression(0)
 				}
 
+				if p.current.Is(Operator, ":") { // slice with step [::2]
+					p.next()
+
+					if !p.current.Is(Bracket, "]") {
+						step = p.parseExpression(0)
+					}
+				}
+
 				node = &SliceNode{
 					Node: node,
+					From: from,
 					To:   to,
+					Step: step,
 				}
 				node.SetLocation(postfixToken.Location)
 				p.expect(Bracket, "]")
 
 			} else {
-
-				from = p.parseExpression(0)
-
-				if p.current.Is(Operator, ":") {
-					p.next()
-
-					if !p.current.Is(Bracket, "]") { // slice without to [1:]
-						to = p.parseExpression(0)
-					}
-
-					node = &SliceNode{
-						Node: node,
-						From: from,
-						To:   to,
-					}
-					node.SetLocation(postfixToken.Location)
-					p.expect(Bracket, "]")
-
-				} else {
-					// Slice operator [:] was not found,
-					// it should be just an index node.
-					node = &MemberNode{
-						Node:     node,
-						Property: from,
-					}
-					node.SetLocation(postfixToken.Location)
-					p.expect(Bracket, "]")
+				// Slice operator [:] was not found,
+				// it should be just an index node.
+				node = &MemberNode{
+					Node:     node,
+					Property: from,
 				}
+				node.SetLocation(postfixToken.Location)
+				p.expect(Bracket, "]")
 			}
 		} else {
 			break
@@ -606,6 +937,7 @@ func isValidIdentifier(str string) bool {
 }
 
 func (p *parser) parseArguments() []Node {
+	open := p.pos
 	p.expect(Bracket, "(")
 	nodes := make([]Node, 0)
 	for !p.current.Is(Bracket, ")") && p.err == nil {
@@ -613,14 +945,35 @@ func (p *parser) parseArguments() []Node {
 			p.expect(Operator, ",")
 		}
 		node := p.parseExpression(0)
+		if p.current.Is(Operator, "...") {
+			spread := &SpreadNode{Node: node}
+			spread.SetLocation(p.current.Location)
+			p.next()
+			if !p.current.Is(Bracket, ")") {
+				p.error("spread argument must be the last one")
+			}
+			node = spread
+		}
 		nodes = append(nodes, node)
+		p.recover(open, ",", ")")
 	}
 	p.expect(Bracket, ")")
 
 	return nodes
 }
 
-func (p *parser) parseArgumentsBuiltin(member builtin.Member) []Node {
+// parseSpread parses ...expr of the array or map literal.
+func (p *parser) parseSpread() Node {
+	token := p.current
+	p.expect(Operator, "...")
+	node := &SpreadNode{Node: p.parseExpression(0)}
+	node.SetLocation(token.Location)
+	return node
+}
+
+// parseArgumentsBuiltin parses arguments of the builtin call. Piped nodes are
+// used as the first arguments.
+func (p *parser) parseArgumentsBuiltin(member builtin.Member, piped ...Node) []Node {
 	if !member.Callable() {
 		return nil
 	}
@@ -631,22 +984,38 @@ func (p *parser) parseArgumentsBuiltin(member builtin.Member) []Node {
 		panic("internal member " + typing.Name(member) + " illegally declared invokable")
 	}
 
-	argMask := builtin.ParserArguments(function)
-	arguments := make([]Node, len(argMask))
-	lastIndex := len(argMask) - 1
+	args := function.Arguments()
+	arguments := make([]Node, 0, len(args))
+	arguments = append(arguments, piped...)
 
+	open := p.pos
 	p.expect(Bracket, "(")
 
-	for i, t := range argMask {
-		switch t {
+	start := len(piped)
+	if start > 0 && args[start-1].Variadic {
+		start-- // Piped node is the first of variadic arguments.
+	}
+
+	for i := start; i < len(args) && p.err == nil; i++ {
+		arg := args[i]
+
+		if (arg.Optional || arg.Variadic || len(arguments) > i) && p.current.Is(Bracket, ")") {
+			break
+		}
+		if len(arguments) > len(piped) {
+			p.expect(Operator, ",")
+		}
+
+		switch arg.ParserType {
 		case builtin.Expression:
-			arguments[i] = p.parseExpression(0)
+			arguments = append(arguments, p.parseExpression(0))
 		case builtin.Closure:
-			arguments[i] = p.parseClosure()
+			arguments = append(arguments, p.parseClosure())
 		}
+		p.recover(open, ",", ")")
 
-		if i != lastIndex {
-			p.expect(Operator, ",")
+		if arg.Variadic {
+			i-- // Parse the same argument again.
 		}
 	}
 
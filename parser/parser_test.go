@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/antonmedv/expr/ast"
 	"github.com/antonmedv/expr/parser"
@@ -370,6 +371,105 @@ func TestParse(t *testing.T) {
 						Left:  &PointerNode{},
 						Right: &IntegerNode{Value: 100}}}}},
 		},
+		{
+			"filter(Prices, p => p > 100)",
+			&BuiltinNode{Name: "filter",
+				Arguments: []Node{&IdentifierNode{Value: "Prices"},
+					&ClosureNode{Params: []string{"p"},
+						Node: &BinaryNode{Operator: ">",
+							Left:  &IdentifierNode{Value: "p"},
+							Right: &IntegerNode{Value: 100}}}}},
+		},
+		{
+			"map(Prices, (p, i) => i)",
+			&BuiltinNode{Name: "map",
+				Arguments: []Node{&IdentifierNode{Value: "Prices"},
+					&ClosureNode{Params: []string{"p", "i"},
+						Node: &IdentifierNode{Value: "i"}}}},
+		},
+		{
+			"let x = a + 1; let y = x; x * y",
+			&VariableDeclaratorNode{
+				Name:  "x",
+				Value: &BinaryNode{Operator: "+", Left: &IdentifierNode{Value: "a"}, Right: &IntegerNode{Value: 1}},
+				Expr: &VariableDeclaratorNode{
+					Name:  "y",
+					Value: &IdentifierNode{Value: "x"},
+					Expr:  &BinaryNode{Operator: "*", Left: &IdentifierNode{Value: "x"}, Right: &IdentifierNode{Value: "y"}}}},
+		},
+		{
+			"a?.b ?? c or d ?? e",
+			&BinaryNode{Operator: "??",
+				Left: &BinaryNode{Operator: "??",
+					Left: &ChainNode{Node: &MemberNode{
+						Node:     &IdentifierNode{Value: "a"},
+						Property: &StringNode{Value: "b"},
+						Optional: true}},
+					Right: &BinaryNode{Operator: "or",
+						Left:  &IdentifierNode{Value: "c"},
+						Right: &IdentifierNode{Value: "d"}}},
+				Right: &IdentifierNode{Value: "e"}},
+		},
+		{
+			"a |> filter({.b}) |> len() > 0",
+			&BinaryNode{Operator: ">",
+				Left: &BuiltinNode{Name: "len",
+					Arguments: []Node{&BuiltinNode{Name: "filter",
+						Arguments: []Node{&IdentifierNode{Value: "a"},
+							&ClosureNode{Node: &MemberNode{Node: &PointerNode{}, Property: &StringNode{Value: "b"}}}}}}},
+				Right: &IntegerNode{Value: 0}},
+		},
+		{
+			"a |> strings.split(',') |> foo(b)",
+			&CallNode{Callee: &IdentifierNode{Value: "foo"},
+				Arguments: []Node{
+					&BuiltinNode{Namespace: "strings", Name: "split",
+						Arguments: []Node{&IdentifierNode{Value: "a"}, &StringNode{Value: ","}}},
+					&IdentifierNode{Value: "b"}}},
+		},
+		{
+			"a |> math.max(b, c)",
+			&BuiltinNode{Namespace: "math", Name: "max",
+				Arguments: []Node{&IdentifierNode{Value: "a"}, &IdentifierNode{Value: "b"}, &IdentifierNode{Value: "c"}}},
+		},
+		{
+			"a | b & 1 << c xor d == e |> f(g) | h()",
+			&BinaryNode{Operator: "|",
+				Left: &CallNode{Callee: &IdentifierNode{Value: "f"},
+					Arguments: []Node{
+						&BinaryNode{Operator: "==",
+							Left: &BinaryNode{Operator: "|",
+								Left: &IdentifierNode{Value: "a"},
+								Right: &BinaryNode{Operator: "xor",
+									Left: &BinaryNode{Operator: "&",
+										Left: &IdentifierNode{Value: "b"},
+										Right: &BinaryNode{Operator: "<<",
+											Left:  &IntegerNode{Value: 1},
+											Right: &IdentifierNode{Value: "c"}}},
+									Right: &IdentifierNode{Value: "d"}}},
+							Right: &IdentifierNode{Value: "e"}},
+						&IdentifierNode{Value: "g"}}},
+				Right: &CallNode{Callee: &IdentifierNode{Value: "h"}, Arguments: []Node{}}},
+		},
+		{
+			"a | f(b)",
+			&BinaryNode{Operator: "|",
+				Left:  &IdentifierNode{Value: "a"},
+				Right: &CallNode{Callee: &IdentifierNode{Value: "f"}, Arguments: []Node{&IdentifierNode{Value: "b"}}}},
+		},
+		{
+			"let + 1",
+			&BinaryNode{Operator: "+", Left: &IdentifierNode{Value: "let"}, Right: &IntegerNode{Value: 1}},
+		},
+		{
+			"reduce(Prices, {#acc + #}, 0)",
+			&BuiltinNode{Name: "reduce",
+				Arguments: []Node{&IdentifierNode{Value: "Prices"},
+					&ClosureNode{Node: &BinaryNode{Operator: "+",
+						Left:  &PointerNode{Name: "acc"},
+						Right: &PointerNode{}}},
+					&IntegerNode{Value: 0}}},
+		},
 		{
 			"array[1:2]",
 			&SliceNode{Node: &IdentifierNode{Value: "array"},
@@ -394,6 +494,89 @@ func TestParse(t *testing.T) {
 			"[]",
 			&ArrayNode{},
 		},
+		{
+			"1h30m",
+			&ConstantNode{Value: 90 * time.Minute},
+		},
+		{
+			`d"2024-01-31"`,
+			&ConstantNode{Value: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			"`Hello ${user.Name}, you have ${len(items)} items`",
+			&TemplateNode{Parts: []Node{
+				&StringNode{Value: "Hello "},
+				&MemberNode{Node: &IdentifierNode{Value: "user"}, Property: &StringNode{Value: "Name"}},
+				&StringNode{Value: ", you have "},
+				&BuiltinNode{Name: "len", Arguments: []Node{&IdentifierNode{Value: "items"}}},
+				&StringNode{Value: " items"}}},
+		},
+		{
+			"``",
+			&TemplateNode{Parts: []Node{}},
+		},
+		{
+			`switch x { case 1, 2 => "low"; case 3..5 => "mid"; case matches "^vip" => "vip"; default => "other"; }`,
+			&SwitchNode{Node: &IdentifierNode{Value: "x"},
+				Cases: []Node{
+					&CaseNode{
+						Conditions: []Node{
+							&BinaryNode{Operator: "==", Left: &IdentifierNode{Value: SwitchSubject}, Right: &IntegerNode{Value: 1}},
+							&BinaryNode{Operator: "==", Left: &IdentifierNode{Value: SwitchSubject}, Right: &IntegerNode{Value: 2}}},
+						Expr: &StringNode{Value: "low"}},
+					&CaseNode{
+						Conditions: []Node{
+							&BinaryNode{Operator: "in", Left: &IdentifierNode{Value: SwitchSubject},
+								Right: &BinaryNode{Operator: "..", Left: &IntegerNode{Value: 3}, Right: &IntegerNode{Value: 5}}}},
+						Expr: &StringNode{Value: "mid"}},
+					&CaseNode{
+						Conditions: []Node{
+							&BinaryNode{Operator: "matches", Left: &IdentifierNode{Value: SwitchSubject}, Right: &StringNode{Value: "^vip"}}},
+						Expr: &StringNode{Value: "vip"}}},
+				Default: &StringNode{Value: "other"}},
+		},
+		{
+			"switch == 1",
+			&BinaryNode{Operator: "==", Left: &IdentifierNode{Value: "switch"}, Right: &IntegerNode{Value: 1}},
+		},
+		{
+			`t"2024-01-31T10:00:00.5Z"`,
+			&ConstantNode{Value: time.Date(2024, 1, 31, 10, 0, 0, 5e8, time.UTC)},
+		},
+		{
+			"a[1:2:3]",
+			&SliceNode{Node: &IdentifierNode{Value: "a"},
+				From: &IntegerNode{Value: 1}, To: &IntegerNode{Value: 2}, Step: &IntegerNode{Value: 3}},
+		},
+		{
+			"a[::-1]",
+			&SliceNode{Node: &IdentifierNode{Value: "a"},
+				Step: &UnaryNode{Operator: "-", Node: &IntegerNode{Value: 1}}},
+		},
+		{
+			"a[:1:]",
+			&SliceNode{Node: &IdentifierNode{Value: "a"}, To: &IntegerNode{Value: 1}},
+		},
+		{
+			"[...a, 1, ...b.c]",
+			&ArrayNode{Nodes: []Node{
+				&SpreadNode{Node: &IdentifierNode{Value: "a"}},
+				&IntegerNode{Value: 1},
+				&SpreadNode{Node: &MemberNode{Node: &IdentifierNode{Value: "b"}, Property: &StringNode{Value: "c"}}}}},
+		},
+		{
+			"{...a, b: 1}",
+			&MapNode{Pairs: []Node{
+				&SpreadNode{Node: &IdentifierNode{Value: "a"}},
+				&PairNode{Key: &StringNode{Value: "b"}, Value: &IntegerNode{Value: 1}}}},
+		},
+		{
+			"foo(a, b + c...)",
+			&CallNode{Callee: &IdentifierNode{Value: "foo"},
+				Arguments: []Node{
+					&IdentifierNode{Value: "a"},
+					&SpreadNode{Node: &BinaryNode{Operator: "+", Left: &IdentifierNode{Value: "b"}, Right: &IdentifierNode{Value: "c"}}}}},
+		},
 	}
 	for _, test := range parseTests {
 		actual, err := parser.Parse(test.input)
@@ -446,6 +629,31 @@ cannot use pointer accessor outside closure (1:1)
  | .foo
  | ^
 
+map(a, (x, i, j) => x)
+closure should have element and optional index params (got 3) (1:18)
+ | map(a, (x, i, j) => x)
+ | .................^
+
+map(a, math => 1)
+cannot use builtin namespace math as closure param (1:8)
+ | map(a, math => 1)
+ | .......^
+
+let x = 1 x
+unexpected token Identifier("x") (1:11)
+ | let x = 1 x
+ | ..........^
+
+a |> math.pi()
+cannot pipe into math.pi (1:13)
+ | a |> math.pi()
+ | ............^
+
+a |> b
+unexpected token EOF (1:6)
+ | a |> b
+ | .....^
+
 [1, 2, 3,,]
 unexpected token Operator(",") (1:10)
  | [1, 2, 3,,]
@@ -465,6 +673,83 @@ a map key must be a quoted string, a number, a identifier, or an expression encl
 unexpected token Operator(",") (1:16)
  | {foo:1, bar:2, ,}
  | ...............^
+
+switch x { default => 1; default => 2 }
+switch has more than one default case (1:26)
+ | switch x { default => 1; default => 2 }
+ | .........................^
+
+switch x { default => 1; case 1 => 2 }
+default case must be the last one in switch (1:26)
+ | switch x { default => 1; case 1 => 2 }
+ | .........................^
+
+switch x {}
+switch must have at least one case (1:11)
+ | switch x {}
+ | ..........^
+
+switch x { case 1 => 2 case 2 => 3 }
+unexpected token Identifier("case") (1:24)
+ | switch x { case 1 => 2 case 2 => 3 }
+ | .......................^
+
+d"2024-02-30"
+invalid date literal: parsing time "2024-02-30": day out of range (1:1)
+ | d"2024-02-30"
+ | ^
+
+1 + t'2024-01-31 10:00'
+invalid date literal: parsing time "2024-01-31 10:00" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse " 10:00" as "T" (1:5)
+ | 1 + t'2024-01-31 10:00'
+ | ....^
+
+foo(a..., b)
+spread argument must be the last one (1:9)
+ | foo(a..., b)
+ | ........^
+
+[a...]
+unexpected token Operator("...") (1:3)
+ | [a...]
+ | ..^
+
+[1 +, 2, foo(, 3)]
+unexpected token Operator(",") (1:5)
+ | [1 +, 2, foo(, 3)]
+ | ....^
+unexpected token Operator(",") (1:14)
+ | [1 +, 2, foo(, 3)]
+ | .............^
+
+{a: , b: 2 2, c: 3}
+unexpected token Operator(",") (1:5)
+ | {a: , b: 2 2, c: 3}
+ | ....^
+unexpected token Number("2") (1:12)
+ | {a: , b: 2 2, c: 3}
+ | ...........^
+
+filter(xs, {# >}) + map(ys, {.a +})
+unexpected token Bracket("}") (1:16)
+ | filter(xs, {# >}) + map(ys, {.a +})
+ | ...............^
+unexpected token Bracket("}") (1:34)
+ | filter(xs, {# >}) + map(ys, {.a +})
+ | .................................^
+
+switch x { case => 1; case 2 => ; default => 3 }
+unexpected token Operator("=>") (1:17)
+ | switch x { case => 1; case 2 => ; default => 3 }
+ | ................^
+unexpected token Operator(";") (1:33)
+ | switch x { case => 1; case 2 => ; default => 3 }
+ | ................................^
+
+[1, 2)
+unexpected token Bracket(")") (1:6)
+ | [1, 2)
+ | .....^
 `
 
 func TestParse_error(t *testing.T) {
@@ -483,6 +768,12 @@ func TestParse_error(t *testing.T) {
 	}
 }
 
+func TestParse_template_error(t *testing.T) {
+	_, err := parser.Parse("`a ${}`")
+	assert.Error(t, err)
+	assert.Equal(t, "unexpected token Bracket(\"}\") (1:6)\n | `a ${}`\n | .....^", err.Error())
+}
+
 func TestParse_optional_chaining(t *testing.T) {
 	parseTests := []struct {
 		input    string
@@ -514,6 +805,33 @@ func TestParse_optional_chaining(t *testing.T) {
 				},
 			},
 		},
+		{
+			"foo?.[0]?.bar",
+			&ChainNode{
+				Node: &MemberNode{
+					Node: &MemberNode{
+						Node:     &IdentifierNode{Value: "foo"},
+						Property: &IntegerNode{Value: 0},
+						Optional: true,
+					},
+					Property: &StringNode{Value: "bar"},
+					Optional: true,
+				},
+			},
+		},
+		{
+			"foo.bar?.(1)",
+			&ChainNode{
+				Node: &CallNode{
+					Callee: &MemberNode{
+						Node:     &IdentifierNode{Value: "foo"},
+						Property: &StringNode{Value: "bar"},
+					},
+					Arguments: []Node{&IntegerNode{Value: 1}},
+					Optional:  true,
+				},
+			},
+		},
 		{
 			"foo?.bar?.baz",
 			&ChainNode{
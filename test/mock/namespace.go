@@ -0,0 +1,62 @@
+package mock
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/builtin"
+	"github.com/antonmedv/expr/util/checking"
+	. "github.com/antonmedv/expr/util/typing"
+	"github.com/antonmedv/expr/vm/runtime"
+)
+
+// Geo is a custom builtin namespace used to test third-party namespaces.
+type Geo struct {
+	builtin.BaseNamespace
+}
+
+func NewGeo() builtin.BuiltinNamespace {
+	return &Geo{
+		builtin.ContainerWith(
+			&GeoDistance{},
+		),
+	}
+}
+
+func (g *Geo) Name() string {
+	return "geo"
+}
+
+type GeoDistance struct {
+	builtin.BaseFunc
+}
+
+func (f *GeoDistance) Name() string {
+	return "distance"
+}
+
+func (f *GeoDistance) Arguments() []builtin.Argument {
+	return []builtin.Argument{
+		{ParserType: builtin.Expression},
+		{ParserType: builtin.Expression},
+	}
+}
+
+func (f *GeoDistance) Visit(v *checking.ExternVisitor, node *ast.BuiltinNode) (reflect.Type, checking.Info) {
+	for _, arg := range node.Arguments {
+		t, _ := v.Visit(arg)
+		if !IsNumber(t) && !IsAny(t) {
+			return v.Error(arg, "geo.distance expects numbers (got %v)", t)
+		}
+	}
+	return FloatType, checking.Info{}
+}
+
+func (f *GeoDistance) Run(args ...interface{}) (interface{}, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, fmt.Errorf("geo.distance expects numbers (got nil)")
+	}
+	return math.Abs(runtime.ToFloat64(args[0]) - runtime.ToFloat64(args[1])), nil
+}
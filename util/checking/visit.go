@@ -4,6 +4,7 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/file"
 )
 
 type Info struct {
@@ -11,8 +12,8 @@ type Info struct {
 }
 
 type VisitFunction func(node ast.Node) (reflect.Type, Info)
-type ErrorFunction func(node ast.Node, format string, args ...interface{}) (reflect.Type, Info)
-type AddCollectionFunction func(collection reflect.Type)
+type ErrorFunction func(node ast.Node, code file.ErrorCode, format string, args ...interface{}) (reflect.Type, Info)
+type AddCollectionFunction func(collection, accumulator reflect.Type)
 type PopCollectionFunction func()
 
 type ExternVisitor struct {
@@ -26,12 +27,27 @@ func (e *ExternVisitor) Visit(node ast.Node) (reflect.Type, Info) {
 	return e.visit(node)
 }
 
+// Error reports a type mismatch of the node, which is the usual error of builtins.
 func (e *ExternVisitor) Error(node ast.Node, format string, args ...interface{}) (reflect.Type, Info) {
-	return e.error(node, format, args)
+	return e.error(node, file.TypeMismatch, format, args...)
+}
+
+// ErrorCode reports an error of the node with the given code.
+func (e *ExternVisitor) ErrorCode(node ast.Node, code file.ErrorCode, format string, args ...interface{}) (reflect.Type, Info) {
+	return e.error(node, code, format, args...)
 }
 
 func (e *ExternVisitor) AddCollection(collection reflect.Type) {
-	e.addCollection(collection)
+	e.addCollection(collection, nil)
+}
+
+// AddReducer is AddCollection for the closure of a reducer, in which #acc
+// has the type of the accumulator, or interface{} if it is nil.
+func (e *ExternVisitor) AddReducer(collection, accumulator reflect.Type) {
+	if accumulator == nil {
+		accumulator = reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	e.addCollection(collection, accumulator)
 }
 
 func (e *ExternVisitor) PopCollection() {
@@ -4,9 +4,13 @@ import (
 	"reflect"
 
 	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/decimal"
 )
 
 func ToNumbertype(a, b reflect.Type) reflect.Type {
+	if IsDecimal(a) || IsDecimal(b) {
+		return DecimalType
+	}
 	if a.Kind() == b.Kind() {
 		return a
 	}
@@ -38,8 +42,14 @@ func IsFloat(t reflect.Type) bool {
 	return false
 }
 
+// IsDecimal reports whether t is decimal.Decimal or a decimal type added
+// with decimal.Register.
+func IsDecimal(t reflect.Type) bool {
+	return decimal.Registered(t)
+}
+
 func IsNumber(t reflect.Type) bool {
-	return IsInteger(t) || IsFloat(t)
+	return IsInteger(t) || IsFloat(t) || IsDecimal(t)
 }
 
 func IsIntegerOrArithmeticOperation(node ast.Node) bool {
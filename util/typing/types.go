@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/antonmedv/expr/conf"
+	"github.com/antonmedv/expr/decimal"
 )
 
 var (
@@ -18,6 +19,7 @@ var (
 	AnyType      = reflect.TypeOf(new(interface{})).Elem()
 	TimeType     = reflect.TypeOf(time.Time{})
 	DurationType = reflect.TypeOf(time.Duration(0))
+	DecimalType  = reflect.TypeOf(decimal.Decimal{})
 	ErrorType    = reflect.TypeOf((*error)(nil)).Elem()
 )
 
@@ -11,7 +11,10 @@ const (
 	OpLoadField
 	OpLoadFast
 	OpLoadMethod
+	OpStore
+	OpLoadVar
 	OpFetch
+	OpFetchOptional
 	OpFetchField
 	OpMethod
 	OpTrue
@@ -37,20 +40,33 @@ const (
 	OpSubtract
 	OpMultiply
 	OpDivide
+	OpDecimalDivide
 	OpModulo
 	OpExponent
+	OpIntDivide
+	OpBitwiseAnd
+	OpBitwiseOr
+	OpBitwiseXor
+	OpShiftLeft
+	OpShiftRight
 	OpRange
 	OpMatches
 	OpMatchesConst
 	OpContains
 	OpStartsWith
 	OpEndsWith
+	OpConcat
 	OpSlice
+	OpSliceStep
 	OpCall
 	OpCallFast
 	OpCallTyped
+	OpCallSpread
+	OpCallBuiltin
 	OpArray
+	OpArraySpread
 	OpMap
+	OpMapSpread
 	OpLen
 	OpCast
 	OpDeref
@@ -58,8 +74,13 @@ const (
 	OpIncrementCount
 	OpGetCount
 	OpGetLen
+	OpGetArray
+	OpGetAcc
+	OpSetAcc
 	OpPointer
+	OpScopeElement
+	OpScopeIndex
+	OpScopeAcc
 	OpBegin
-	OpAbs // namespace math
 	OpEnd // This opcode must be at the end of this list.
 )
@@ -17,6 +17,7 @@ type Program struct {
 	Constants []interface{}
 	Bytecode  []Opcode
 	Arguments []int
+	Variables int // number of slots for let bindings
 }
 
 func (program *Program) Disassemble() string {
@@ -56,6 +57,9 @@ func (program *Program) Disassemble() string {
 			if method, ok := c.(*runtime.Method); ok {
 				c = fmt.Sprintf("{%v %v}", method.Name, method.Index)
 			}
+			if builtin, ok := c.(*runtime.Builtin); ok {
+				c = fmt.Sprintf("{%v %v}", builtin.Name, builtin.Arity)
+			}
 			out += fmt.Sprintf("%v\t%v\t%v\t%v\n", pp, label, arg, c)
 		}
 
@@ -81,12 +85,21 @@ func (program *Program) Disassemble() string {
 		case OpLoadFast:
 			constant("OpLoadFast")
 
+		case OpStore:
+			argument("OpStore")
+
+		case OpLoadVar:
+			argument("OpLoadVar")
+
 		case OpLoadMethod:
 			constant("OpLoadMethod")
 
 		case OpFetch:
 			code("OpFetch")
 
+		case OpFetchOptional:
+			code("OpFetchOptional")
+
 		case OpFetchField:
 			constant("OpFetchField")
 
@@ -162,12 +175,33 @@ func (program *Program) Disassemble() string {
 		case OpDivide:
 			code("OpDivide")
 
+		case OpDecimalDivide:
+			code("OpDecimalDivide")
+
 		case OpModulo:
 			code("OpModulo")
 
 		case OpExponent:
 			code("OpExponent")
 
+		case OpIntDivide:
+			code("OpIntDivide")
+
+		case OpBitwiseAnd:
+			code("OpBitwiseAnd")
+
+		case OpBitwiseOr:
+			code("OpBitwiseOr")
+
+		case OpBitwiseXor:
+			code("OpBitwiseXor")
+
+		case OpShiftLeft:
+			code("OpShiftLeft")
+
+		case OpShiftRight:
+			code("OpShiftRight")
+
 		case OpRange:
 			code("OpRange")
 
@@ -186,9 +220,15 @@ func (program *Program) Disassemble() string {
 		case OpEndsWith:
 			code("OpEndsWith")
 
+		case OpConcat:
+			argument("OpConcat")
+
 		case OpSlice:
 			code("OpSlice")
 
+		case OpSliceStep:
+			code("OpSliceStep")
+
 		case OpCall:
 			argument("OpCall")
 
@@ -198,12 +238,24 @@ func (program *Program) Disassemble() string {
 		case OpCallTyped:
 			argument("OpCallTyped")
 
+		case OpCallSpread:
+			argument("OpCallSpread")
+
+		case OpCallBuiltin:
+			constant("OpCallBuiltin")
+
 		case OpArray:
 			code("OpArray")
 
+		case OpArraySpread:
+			constant("OpArraySpread")
+
 		case OpMap:
 			code("OpMap")
 
+		case OpMapSpread:
+			constant("OpMapSpread")
+
 		case OpLen:
 			code("OpLen")
 
@@ -225,9 +277,27 @@ func (program *Program) Disassemble() string {
 		case OpGetLen:
 			code("OpGetLen")
 
+		case OpGetArray:
+			code("OpGetArray")
+
+		case OpGetAcc:
+			code("OpGetAcc")
+
+		case OpSetAcc:
+			code("OpSetAcc")
+
 		case OpPointer:
 			code("OpPointer")
 
+		case OpScopeElement:
+			argument("OpScopeElement")
+
+		case OpScopeIndex:
+			argument("OpScopeIndex")
+
+		case OpScopeAcc:
+			argument("OpScopeAcc")
+
 		case OpBegin:
 			code("OpBegin")
 
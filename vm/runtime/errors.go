@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/antonmedv/expr/decimal"
+	"github.com/antonmedv/expr/file"
+)
+
+// Error is an error of running an expression with its code. The runtime
+// panics with it, and builtins may return it to report the code.
+type Error struct {
+	Code    file.ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an Error with the code and the formatted message.
+func Errorf(code file.ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode returns the code of the value of a panic raised while running
+// (or folding) an expression, or of an error returned by a function. Only
+// Error carries a code, everything else is a RuntimeError.
+func ErrorCode(r interface{}) file.ErrorCode {
+	switch e := r.(type) {
+	case *Error:
+		return e.Code
+	case error:
+		if e == decimal.ErrDivisionByZero {
+			return file.DivisionByZero
+		}
+	}
+	return file.RuntimeError
+}
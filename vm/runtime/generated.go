@@ -3,9 +3,10 @@
 package runtime
 
 import (
-	"fmt"
 	"reflect"
 	"time"
+
+	"github.com/antonmedv/expr/file"
 )
 
 func Equal(a, b interface{}) bool {
@@ -345,6 +346,9 @@ func Equal(a, b interface{}) bool {
 			return x.Equal(y)
 		}
 	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Equal(y)
+	}
 	if IsNil(a) && IsNil(b) {
 		return true
 	}
@@ -687,8 +691,16 @@ func Less(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x < y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) < 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T < %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T < %T", a, b))
 }
 
 func More(a, b interface{}) bool {
@@ -1027,8 +1039,16 @@ func More(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x > y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) > 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T > %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T > %T", a, b))
 }
 
 func LessOrEqual(a, b interface{}) bool {
@@ -1367,8 +1387,16 @@ func LessOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y) || x.Equal(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x <= y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) <= 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T <= %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T <= %T", a, b))
 }
 
 func MoreOrEqual(a, b interface{}) bool {
@@ -1707,8 +1735,16 @@ func MoreOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y) || x.Equal(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x >= y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) >= 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T >= %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T >= %T", a, b))
 }
 
 func Add(a, b interface{}) interface{} {
@@ -2051,9 +2087,14 @@ func Add(a, b interface{}) interface{} {
 		switch y := b.(type) {
 		case time.Time:
 			return y.Add(x)
+		case time.Duration:
+			return x + y
 		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T + %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Add(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T + %T", a, b))
 }
 
 func Subtract(a, b interface{}) interface{} {
@@ -2387,8 +2428,16 @@ func Subtract(a, b interface{}) interface{} {
 		case time.Time:
 			return x.Sub(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x - y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Sub(y)
 	}
-	panic(fmt.Sprintf("invalid operation: %T - %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T - %T", a, b))
 }
 
 func Multiply(a, b interface{}) interface{} {
@@ -2717,11 +2766,23 @@ func Multiply(a, b interface{}) interface{} {
 		case float64:
 			return float64(x) * float64(y)
 		}
+	case time.Duration:
+		if d, ok := scaleDuration(x, b, false); ok {
+			return d
+		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T * %T", a, b))
+	if y, ok := b.(time.Duration); ok {
+		if d, ok := scaleDuration(y, a, false); ok {
+			return d
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Mul(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T * %T", a, b))
 }
 
-func Divide(a, b interface{}) float64 {
+func Divide(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	case uint:
 		switch y := b.(type) {
@@ -3047,11 +3108,23 @@ func Divide(a, b interface{}) float64 {
 		case float64:
 			return float64(x) / float64(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return float64(x) / float64(y)
+		}
+		if d, ok := scaleDuration(x, b, true); ok {
+			return d
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Div(y)
 	}
-	panic(fmt.Sprintf("invalid operation: %T / %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T / %T", a, b))
 }
 
-func Modulo(a, b interface{}) int {
+func Modulo(a, b interface{}) interface{} {
+	checkDivisor(b)
 	switch x := a.(type) {
 	case uint:
 		switch y := b.(type) {
@@ -3284,5 +3357,1427 @@ func Modulo(a, b interface{}) int {
 			return int(x) % int(y)
 		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T %% %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Mod(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T %% %T", a, b))
+}
+
+func IntDivide(a, b interface{}) int {
+	checkDivisor(b)
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) / int(y)
+		case uint8:
+			return int(x) / int(y)
+		case uint16:
+			return int(x) / int(y)
+		case uint32:
+			return int(x) / int(y)
+		case uint64:
+			return int(x) / int(y)
+		case int:
+			return int(x) / int(y)
+		case int8:
+			return int(x) / int(y)
+		case int16:
+			return int(x) / int(y)
+		case int32:
+			return int(x) / int(y)
+		case int64:
+			return int(x) / int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T div %T", a, b))
+}
+
+func BitwiseAnd(a, b interface{}) int {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) & int(y)
+		case uint8:
+			return int(x) & int(y)
+		case uint16:
+			return int(x) & int(y)
+		case uint32:
+			return int(x) & int(y)
+		case uint64:
+			return int(x) & int(y)
+		case int:
+			return int(x) & int(y)
+		case int8:
+			return int(x) & int(y)
+		case int16:
+			return int(x) & int(y)
+		case int32:
+			return int(x) & int(y)
+		case int64:
+			return int(x) & int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T & %T", a, b))
+}
+
+func BitwiseOr(a, b interface{}) int {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) | int(y)
+		case uint8:
+			return int(x) | int(y)
+		case uint16:
+			return int(x) | int(y)
+		case uint32:
+			return int(x) | int(y)
+		case uint64:
+			return int(x) | int(y)
+		case int:
+			return int(x) | int(y)
+		case int8:
+			return int(x) | int(y)
+		case int16:
+			return int(x) | int(y)
+		case int32:
+			return int(x) | int(y)
+		case int64:
+			return int(x) | int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T | %T", a, b))
+}
+
+func BitwiseXor(a, b interface{}) int {
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) ^ int(y)
+		case uint8:
+			return int(x) ^ int(y)
+		case uint16:
+			return int(x) ^ int(y)
+		case uint32:
+			return int(x) ^ int(y)
+		case uint64:
+			return int(x) ^ int(y)
+		case int:
+			return int(x) ^ int(y)
+		case int8:
+			return int(x) ^ int(y)
+		case int16:
+			return int(x) ^ int(y)
+		case int32:
+			return int(x) ^ int(y)
+		case int64:
+			return int(x) ^ int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T xor %T", a, b))
+}
+
+func ShiftLeft(a, b interface{}) int {
+	checkShift(b)
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) << int(y)
+		case uint8:
+			return int(x) << int(y)
+		case uint16:
+			return int(x) << int(y)
+		case uint32:
+			return int(x) << int(y)
+		case uint64:
+			return int(x) << int(y)
+		case int:
+			return int(x) << int(y)
+		case int8:
+			return int(x) << int(y)
+		case int16:
+			return int(x) << int(y)
+		case int32:
+			return int(x) << int(y)
+		case int64:
+			return int(x) << int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T << %T", a, b))
+}
+
+func ShiftRight(a, b interface{}) int {
+	checkShift(b)
+	switch x := a.(type) {
+	case uint:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case uint8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case uint16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case uint32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case int:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case int8:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case int16:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case int32:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case uint:
+			return int(x) >> int(y)
+		case uint8:
+			return int(x) >> int(y)
+		case uint16:
+			return int(x) >> int(y)
+		case uint32:
+			return int(x) >> int(y)
+		case uint64:
+			return int(x) >> int(y)
+		case int:
+			return int(x) >> int(y)
+		case int8:
+			return int(x) >> int(y)
+		case int16:
+			return int(x) >> int(y)
+		case int32:
+			return int(x) >> int(y)
+		case int64:
+			return int(x) >> int(y)
+		}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T >> %T", a, b))
 }
@@ -66,7 +66,7 @@ func cases(op string, noFloat bool) string {
 				t = "float64"
 			}
 			echo(`case %v:`, b)
-			if op == "/" {
+			if op == "/" && !noFloat {
 				echo(`return float64(x) / float64(y)`)
 			} else {
 				echo(`return %v(x) %v %v(y)`, t, op, t)
@@ -82,9 +82,10 @@ const helpers = `// Code generated by vm/runtime/helpers/main.go. DO NOT EDIT.
 package runtime
 
 import (
-	"fmt"
 	"reflect"
 	"time"
+
+	"github.com/antonmedv/expr/file"
 )
 
 func Equal(a, b interface{}) bool {
@@ -101,6 +102,9 @@ func Equal(a, b interface{}) bool {
 			return x.Equal(y)
 		}
 	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Equal(y)
+	}
 	if IsNil(a) && IsNil(b) {
 		return true
 	}
@@ -120,8 +124,16 @@ func Less(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x < y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) < 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T < %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T < %T", a, b))
 }
 
 func More(a, b interface{}) bool {
@@ -137,8 +149,16 @@ func More(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x > y
+		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T > %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) > 0
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T > %T", a, b))
 }
 
 func LessOrEqual(a, b interface{}) bool {
@@ -154,8 +174,16 @@ func LessOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.Before(y) || x.Equal(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x <= y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) <= 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T <= %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T <= %T", a, b))
 }
 
 func MoreOrEqual(a, b interface{}) bool {
@@ -171,8 +199,16 @@ func MoreOrEqual(a, b interface{}) bool {
 		case time.Time:
 			return x.After(y) || x.Equal(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x >= y
+		}
+	}
+	if x, y, ok := decimals(a, b); ok {
+		return x.Cmp(y) >= 0
 	}
-	panic(fmt.Sprintf("invalid operation: %T >= %T", a, b))
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T >= %T", a, b))
 }
 
 func Add(a, b interface{}) interface{} {
@@ -192,9 +228,14 @@ func Add(a, b interface{}) interface{} {
 		switch y := b.(type) {
 		case time.Time:
 			return y.Add(x)
+		case time.Duration:
+			return x + y
 		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T + %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Add(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T + %T", a, b))
 }
 
 func Subtract(a, b interface{}) interface{} {
@@ -205,28 +246,108 @@ func Subtract(a, b interface{}) interface{} {
 		case time.Time:
 			return x.Sub(y)
 		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return x - y
+		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T - %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Sub(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T - %T", a, b))
 }
 
 func Multiply(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	{{ cases "*" }}
+	case time.Duration:
+		if d, ok := scaleDuration(x, b, false); ok {
+			return d
+		}
+	}
+	if y, ok := b.(time.Duration); ok {
+		if d, ok := scaleDuration(y, a, false); ok {
+			return d
+		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T * %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Mul(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T * %T", a, b))
 }
 
-func Divide(a, b interface{}) float64 {
+func Divide(a, b interface{}) interface{} {
 	switch x := a.(type) {
 	{{ cases "/" }}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Duration:
+			return float64(x) / float64(y)
+		}
+		if d, ok := scaleDuration(x, b, true); ok {
+			return d
+		}
 	}
-	panic(fmt.Sprintf("invalid operation: %T / %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Div(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T / %T", a, b))
 }
 
-func Modulo(a, b interface{}) int {
+func Modulo(a, b interface{}) interface{} {
+	checkDivisor(b)
 	switch x := a.(type) {
 	{{ cases_int_only "%" }}
 	}
-	panic(fmt.Sprintf("invalid operation: %T %% %T", a, b))
+	if x, y, ok := decimals(a, b); ok {
+		return x.Mod(y)
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T %% %T", a, b))
+}
+
+func IntDivide(a, b interface{}) int {
+	checkDivisor(b)
+	switch x := a.(type) {
+	{{ cases_int_only "/" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T div %T", a, b))
+}
+
+func BitwiseAnd(a, b interface{}) int {
+	switch x := a.(type) {
+	{{ cases_int_only "&" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T & %T", a, b))
+}
+
+func BitwiseOr(a, b interface{}) int {
+	switch x := a.(type) {
+	{{ cases_int_only "|" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T | %T", a, b))
+}
+
+func BitwiseXor(a, b interface{}) int {
+	switch x := a.(type) {
+	{{ cases_int_only "^" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T xor %T", a, b))
+}
+
+func ShiftLeft(a, b interface{}) int {
+	checkShift(b)
+	switch x := a.(type) {
+	{{ cases_int_only "<<" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T << %T", a, b))
+}
+
+func ShiftRight(a, b interface{}) int {
+	checkShift(b)
+	switch x := a.(type) {
+	{{ cases_int_only ">>" }}
+	}
+	panic(Errorf(file.TypeMismatch, "invalid operation: %T >> %T", a, b))
 }
 `
@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antonmedv/expr/decimal"
+	"github.com/antonmedv/expr/file"
 )
 
 func Fetch(from, i interface{}) interface{} {
 	v := reflect.ValueOf(from)
 	kind := v.Kind()
 	if kind == reflect.Invalid {
-		panic(fmt.Sprintf("cannot fetch %v from %T", i, from))
+		panic(Errorf(file.TypeMismatch, "cannot fetch %v from %T", i, from))
 	}
 
 	// Methods can be defined on any type.
@@ -33,8 +39,27 @@ func Fetch(from, i interface{}) interface{} {
 
 	switch kind {
 
-	case reflect.Array, reflect.Slice, reflect.String:
-		value := v.Index(ToInt(i))
+	case reflect.String:
+		// Strings are indexed by runes, like they are sliced.
+		runes := []rune(v.String())
+		index := ToInt(i)
+		if index < 0 {
+			index += len(runes)
+		}
+		if index < 0 || index >= len(runes) {
+			panic(Errorf(file.IndexOutOfRange, "index out of range: %v (string length is %v)", i, len(runes)))
+		}
+		return string(runes[index])
+
+	case reflect.Array, reflect.Slice:
+		index := ToInt(i)
+		if index < 0 {
+			index += v.Len() // Negative index counts from the end: a[-1] is the last element.
+		}
+		if index < 0 || index >= v.Len() {
+			panic(Errorf(file.IndexOutOfRange, "index out of range: %v (array length is %v)", i, v.Len()))
+		}
+		value := v.Index(index)
 		if value.IsValid() {
 			return value.Interface()
 		}
@@ -61,7 +86,94 @@ func Fetch(from, i interface{}) interface{} {
 			return value.Interface()
 		}
 	}
-	panic(fmt.Sprintf("cannot fetch %v from %T", i, from))
+	panic(Errorf(file.TypeMismatch, "cannot fetch %v from %T", i, from))
+}
+
+// Concat joins the parts of a template literal into a string. Parts which
+// are not strings are formatted with their default format.
+func Concat(parts []interface{}) string {
+	var b strings.Builder
+	for _, part := range parts {
+		if s, ok := part.(string); ok {
+			b.WriteString(s)
+		} else {
+			fmt.Fprint(&b, part)
+		}
+	}
+	return b.String()
+}
+
+// Spread appends the elements of the array (or slice) v to the array.
+func Spread(array []interface{}, v interface{}) []interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			array = append(array, rv.Index(i).Interface())
+		}
+		return array
+	}
+	panic(Errorf(file.TypeMismatch, "cannot spread %T in array", v))
+}
+
+// SpreadMap copies the entries of the map v into the map m. Keys of v must
+// be strings.
+func SpreadMap(m map[string]interface{}, v interface{}) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Map {
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, ok := iter.Key().Interface().(string)
+			if !ok {
+				panic(Errorf(file.TypeMismatch, "cannot spread %T in map (keys must be strings)", v))
+			}
+			m[key] = iter.Value().Interface()
+		}
+		return
+	}
+	panic(Errorf(file.TypeMismatch, "cannot spread %T in map", v))
+}
+
+// Variadic converts the elements of the array (or slice) v to the variadic
+// arguments of the func type fn.
+func Variadic(fn reflect.Type, v interface{}) []reflect.Value {
+	in := fn.In(fn.NumIn() - 1).Elem()
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+	default:
+		panic(Errorf(file.TypeMismatch, "cannot use %T as variadic argument", v))
+	}
+	args := make([]reflect.Value, rv.Len())
+	for i := range args {
+		arg := rv.Index(i)
+		if arg.Kind() == reflect.Interface {
+			if arg.IsNil() {
+				args[i] = reflect.Zero(in)
+				continue
+			}
+			arg = arg.Elem()
+		}
+		if !arg.Type().AssignableTo(in) {
+			if !arg.Type().ConvertibleTo(in) {
+				panic(Errorf(file.TypeMismatch, "cannot use %v as %v in variadic argument", arg.Type(), in))
+			}
+			arg = arg.Convert(in)
+		}
+		args[i] = arg
+	}
+	return args
+}
+
+// FetchOptional is like Fetch, but returns nil instead of panicking, if the
+// element cannot be fetched, for example, if the index is out of range.
+func FetchOptional(from, i interface{}) (out interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			out = nil
+		}
+	}()
+	return Fetch(from, i)
 }
 
 type Field struct {
@@ -88,7 +200,7 @@ func FetchField(from interface{}, field *Field) interface{} {
 			return value.Interface()
 		}
 	}
-	panic(fmt.Sprintf("cannot get %v from %T", field.Path[0], from))
+	panic(Errorf(file.TypeMismatch, "cannot get %v from %T", field.Path[0], from))
 }
 
 func fieldByIndex(v reflect.Value, field *Field) reflect.Value {
@@ -99,7 +211,7 @@ func fieldByIndex(v reflect.Value, field *Field) reflect.Value {
 		if i > 0 {
 			if v.Kind() == reflect.Ptr {
 				if v.IsNil() {
-					panic(fmt.Sprintf("cannot get %v from %v", field.Path[i], field.Path[i-1]))
+					panic(Errorf(file.TypeMismatch, "cannot get %v from %v", field.Path[i], field.Path[i-1]))
 				}
 				v = v.Elem()
 			}
@@ -124,7 +236,7 @@ func FetchMethod(from interface{}, method *Method) interface{} {
 			return method.Interface()
 		}
 	}
-	panic(fmt.Sprintf("cannot fetch %v from %T", method.Name, from))
+	panic(Errorf(file.TypeMismatch, "cannot fetch %v from %T", method.Name, from))
 }
 
 func Deref(i interface{}) interface{} {
@@ -160,14 +272,30 @@ func Deref(i interface{}) interface{} {
 	panic(fmt.Sprintf("cannot dereference %v", i))
 }
 
+// Slice is array[from:to]. Strings are sliced by runes.
 func Slice(array, from, to interface{}) interface{} {
 	v := reflect.ValueOf(array)
 
 	switch v.Kind() {
-	case reflect.Array, reflect.Slice, reflect.String:
+	case reflect.String:
+		return string(Slice([]rune(v.String()), from, to).([]rune))
+
+	case reflect.Array, reflect.Slice:
 		length := v.Len()
 		a, b := ToInt(from), ToInt(to)
 
+		if a < 0 {
+			a += length
+			if a < 0 {
+				a = 0
+			}
+		}
+		if b < 0 {
+			b += length
+			if b < 0 {
+				b = 0
+			}
+		}
 		if b > length {
 			b = length
 		}
@@ -187,7 +315,69 @@ func Slice(array, from, to interface{}) interface{} {
 		}
 
 	}
-	panic(fmt.Sprintf("cannot slice %v", from))
+	panic(Errorf(file.TypeMismatch, "cannot slice %v", from))
+}
+
+// SliceStep is array[from:to:step]. Omitted from and to are nil, and
+// depend on the sign of the step: array[::-1] is the reversed array.
+// Strings are sliced by runes, like in Slice.
+func SliceStep(array, from, to, step interface{}) interface{} {
+	v := reflect.Indirect(reflect.ValueOf(array))
+	if v.Kind() == reflect.String {
+		runes := SliceStep([]rune(v.String()), from, to, step)
+		return string(runes.([]rune))
+	}
+
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		length := v.Len()
+		s := ToInt(step)
+		if s == 0 {
+			panic(Errorf(file.InvalidArgument, "slice step cannot be zero"))
+		}
+
+		// Bounds are clamped to [0, length] for the positive step
+		// and to [-1, length-1] for the negative one.
+		lower, upper := 0, length
+		if s < 0 {
+			lower, upper = -1, length-1
+		}
+		bound := func(i interface{}, def int) int {
+			if i == nil {
+				return def
+			}
+			n := ToInt(i)
+			if n < 0 {
+				n += length
+			}
+			if n < lower {
+				return lower
+			}
+			if n > upper {
+				return upper
+			}
+			return n
+		}
+
+		var a, b int
+		if s > 0 {
+			a, b = bound(from, lower), bound(to, upper)
+		} else {
+			a, b = bound(from, upper), bound(to, lower)
+		}
+
+		var indexes []int
+		for i := a; (s > 0 && i < b) || (s < 0 && i > b); i += s {
+			indexes = append(indexes, i)
+		}
+
+		out := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(indexes), len(indexes))
+		for j, i := range indexes {
+			out.Index(j).Set(v.Index(i))
+		}
+		return out.Interface()
+	}
+	panic(Errorf(file.TypeMismatch, "cannot slice %T", array))
 }
 
 func In(needle interface{}, array interface{}) bool {
@@ -212,7 +402,7 @@ func In(needle interface{}, array interface{}) bool {
 	case reflect.Map:
 		n := reflect.ValueOf(needle)
 		if !n.IsValid() {
-			panic(fmt.Sprintf("cannot use %T as index to %T", needle, array))
+			panic(Errorf(file.TypeMismatch, "cannot use %T as index to %T", needle, array))
 		}
 		value := v.MapIndex(n)
 		if value.IsValid() {
@@ -223,7 +413,7 @@ func In(needle interface{}, array interface{}) bool {
 	case reflect.Struct:
 		n := reflect.ValueOf(needle)
 		if !n.IsValid() || n.Kind() != reflect.String {
-			panic(fmt.Sprintf("cannot use %T as field name of %T", needle, array))
+			panic(Errorf(file.TypeMismatch, "cannot use %T as field name of %T", needle, array))
 		}
 		value := v.FieldByName(n.String())
 		if value.IsValid() {
@@ -248,7 +438,7 @@ func Length(a interface{}) int {
 	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
 		return v.Len()
 	default:
-		panic(fmt.Sprintf("invalid argument for len (type %T)", a))
+		panic(Errorf(file.TypeMismatch, "invalid argument for len (type %T)", a))
 	}
 }
 
@@ -278,12 +468,25 @@ func Negate(i interface{}) interface{} {
 		return -v
 	case uint64:
 		return -v
+	case time.Duration:
+		return -v
 	default:
-		panic(fmt.Sprintf("invalid operation: - %T", v))
+		if d, ok := decimal.From(v); ok {
+			return d.Neg()
+		}
+		panic(Errorf(file.TypeMismatch, "invalid operation: - %T", v))
 	}
 }
 
-func Exponent(a, b interface{}) float64 {
+// Exponent is a ** b. It is a decimal, if a or b is a decimal, otherwise it
+// is a float.
+func Exponent(a, b interface{}) interface{} {
+	if x, y, ok := decimals(a, b); ok {
+		if y.Equal(y.Truncate()) && math.Abs(y.Float64()) <= math.MaxInt32 {
+			return x.PowInt(int(y.Int64()))
+		}
+		return fromFloat(math.Pow(x.Float64(), y.Float64()))
+	}
 	return math.Pow(ToFloat64(a), ToFloat64(b))
 }
 
@@ -326,7 +529,10 @@ func ToInt(a interface{}) int {
 	case uint64:
 		return int(x)
 	default:
-		panic(fmt.Sprintf("invalid operation: int(%T)", x))
+		if d, ok := decimal.From(x); ok {
+			return int(d.Int64())
+		}
+		panic(Errorf(file.TypeMismatch, "invalid operation: int(%T)", x))
 	}
 }
 
@@ -357,10 +563,62 @@ func ToInt64(a interface{}) int64 {
 	case uint64:
 		return int64(x)
 	default:
-		panic(fmt.Sprintf("invalid operation: int64(%T)", x))
+		if d, ok := decimal.From(x); ok {
+			return d.Int64()
+		}
+		panic(Errorf(file.TypeMismatch, "invalid operation: int64(%T)", x))
 	}
 }
 
+// ToIntChecked converts a number to int like ToInt, but fails with an
+// InvalidArgument error if the number is out of range of int instead of
+// wrapping around.
+func ToIntChecked(a interface{}) (int, error) {
+	n, ok := toInt64Checked(a)
+	if !ok || int64(int(n)) != n {
+		return 0, Errorf(file.InvalidArgument, "cannot convert %v to int (out of range)", a)
+	}
+	return int(n), nil
+}
+
+// ToInt64Checked is ToIntChecked for int64.
+func ToInt64Checked(a interface{}) (int64, error) {
+	n, ok := toInt64Checked(a)
+	if !ok {
+		return 0, Errorf(file.InvalidArgument, "cannot convert %v to int64 (out of range)", a)
+	}
+	return n, nil
+}
+
+func toInt64Checked(a interface{}) (int64, bool) {
+	switch x := a.(type) {
+	case float32:
+		return floatToInt64(float64(x))
+	case float64:
+		return floatToInt64(x)
+	case uint:
+		return int64(x), uint64(x) <= math.MaxInt64
+	case uint64:
+		return int64(x), x <= math.MaxInt64
+	}
+	if d, ok := decimal.From(a); ok {
+		t := d.Truncate()
+		if t.Cmp(decimal.NewFromInt(math.MaxInt64)) > 0 || t.Cmp(decimal.NewFromInt(math.MinInt64)) < 0 {
+			return 0, false
+		}
+		return d.Int64(), true
+	}
+	return ToInt64(a), true
+}
+
+// floatToInt64 truncates x, which must be in [-2^63, 2^63), NaN is not.
+func floatToInt64(x float64) (int64, bool) {
+	if x >= -(1<<63) && x < 1<<63 {
+		return int64(x), true
+	}
+	return 0, false
+}
+
 func ToFloat64(a interface{}) float64 {
 	switch x := a.(type) {
 	case float32:
@@ -388,8 +646,113 @@ func ToFloat64(a interface{}) float64 {
 	case uint64:
 		return float64(x)
 	default:
-		panic(fmt.Sprintf("invalid operation: float64(%T)", x))
+		if d, ok := decimal.From(x); ok {
+			return d.Float64()
+		}
+		panic(Errorf(file.TypeMismatch, "invalid operation: float64(%T)", x))
+	}
+}
+
+// checkDivisor panics with DivisionByZero, if the divisor of the integer
+// division is zero, which otherwise is a panic of Go without a code.
+func checkDivisor(b interface{}) {
+	v := reflect.ValueOf(b)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() == 0 {
+			panic(Errorf(file.DivisionByZero, "runtime error: integer divide by zero"))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() == 0 {
+			panic(Errorf(file.DivisionByZero, "runtime error: integer divide by zero"))
+		}
+	}
+}
+
+// checkShift panics with InvalidArgument, if the shift amount is negative.
+func checkShift(b interface{}) {
+	v := reflect.ValueOf(b)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			panic(Errorf(file.InvalidArgument, "negative shift amount"))
+		}
+	}
+}
+
+// DecimalDivide divides numbers as decimals. It is used for "/" in the
+// decimal mode, where the division of integers results in a decimal.
+func DecimalDivide(a, b interface{}) decimal.Decimal {
+	x, ok := toDecimal(a)
+	y, ok2 := toDecimal(b)
+	if !ok || !ok2 {
+		panic(Errorf(file.TypeMismatch, "invalid operation: %T / %T", a, b))
+	}
+	return x.Div(y)
+}
+
+// decimals converts both numbers to decimals, if at least one of them is
+// a decimal.
+func decimals(a, b interface{}) (decimal.Decimal, decimal.Decimal, bool) {
+	_, ok := decimal.From(a)
+	_, ok2 := decimal.From(b)
+	if !ok && !ok2 {
+		return decimal.Decimal{}, decimal.Decimal{}, false
+	}
+	x, ok := toDecimal(a)
+	y, ok2 := toDecimal(b)
+	return x, y, ok && ok2
+}
+
+// ToDecimal converts the number to a decimal.
+func ToDecimal(a interface{}) decimal.Decimal {
+	d, ok := toDecimal(a)
+	if !ok {
+		panic(Errorf(file.TypeMismatch, "invalid operation: decimal(%T)", a))
+	}
+	return d
+}
+
+func toDecimal(a interface{}) (decimal.Decimal, bool) {
+	switch x := a.(type) {
+	case float32, float64:
+		return fromFloat(ToFloat64(x)), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+		return decimal.NewFromInt(ToInt64(x)), true
+	case uint64:
+		return decimal.MustParse(fmt.Sprint(x)), true
+	}
+	return decimal.From(a)
+}
+
+// fromFloat converts the float to a decimal, NaN and infinities are not
+// decimals.
+func fromFloat(f float64) decimal.Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(Errorf(file.InvalidArgument, "cannot convert %v to decimal", f))
+	}
+	return decimal.NewFromFloat(f)
+}
+
+// scaleDuration multiplies or divides the duration by the number. It reports
+// false, if n is not a number.
+func scaleDuration(d time.Duration, n interface{}, divide bool) (time.Duration, bool) {
+	switch n.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if divide {
+			return d / time.Duration(ToInt64(n)), true
+		}
+		return d * time.Duration(ToInt64(n)), true
+	case float32, float64:
+	default:
+		if _, ok := decimal.From(n); !ok {
+			return 0, false
+		}
 	}
+	if divide {
+		return time.Duration(float64(d) / ToFloat64(n)), true
+	}
+	return time.Duration(float64(d) * ToFloat64(n)), true
 }
 
 func IsNil(v interface{}) bool {
@@ -404,3 +767,66 @@ func IsNil(v interface{}) bool {
 		return false
 	}
 }
+
+// EntryType returns the type of elements, which closures get as # while
+// iterating over a map of type t.
+func EntryType(t reflect.Type) reflect.Type {
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "Key", Type: t.Key()},
+		{Name: "Value", Type: t.Elem()},
+	})
+}
+
+// Entries returns a slice of key-value pairs of the map.
+func Entries(m reflect.Value) reflect.Value {
+	keys := MapKeys(m)
+	entries := reflect.MakeSlice(reflect.SliceOf(EntryType(m.Type())), len(keys), len(keys))
+	for i, key := range keys {
+		entries.Index(i).Field(0).Set(key)
+		entries.Index(i).Field(1).Set(m.MapIndex(key))
+	}
+	return entries
+}
+
+// MapKeys returns keys of the map. Unlike reflect.Value.MapKeys the order of
+// keys is stable: numbers and strings are sorted, other keys are grouped by
+// kind.
+func MapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessKey(keys[i], keys[j])
+	})
+	return keys
+}
+
+func lessKey(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	}
+	return false
+}
+
+// Builtin is a member of a builtin namespace executed as a Go function.
+type Builtin struct {
+	Name  string
+	Arity int
+	Func  func(args ...interface{}) (interface{}, error)
+}
@@ -4,7 +4,6 @@ package vm
 
 import (
 	"fmt"
-	"math"
 	"reflect"
 	"regexp"
 	"strings"
@@ -17,6 +16,9 @@ var (
 	MemoryBudget int = 1e6
 )
 
+// errMemoryBudgetExceeded is the panic of allocations over the MemoryBudget.
+var errMemoryBudgetExceeded = runtime.Errorf(file.MemoryBudgetExceeded, "memory budget exceeded")
+
 func Run(program *Program, env interface{}) (interface{}, error) {
 	if program == nil {
 		return nil, fmt.Errorf("program is nil")
@@ -30,6 +32,7 @@ type VM struct {
 	stack        []interface{}
 	ip           int
 	scopes       []*Scope
+	variables    []interface{}
 	debug        bool
 	step         chan struct{}
 	curr         chan int
@@ -42,6 +45,7 @@ type Scope struct {
 	It    int
 	Len   int
 	Count int
+	Acc   interface{}
 }
 
 func Debug() *VM {
@@ -56,9 +60,11 @@ func Debug() *VM {
 func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			msg := fmt.Sprintf("%v", r)
 			f := &file.Error{
 				Location: program.Locations[vm.ip-1],
-				Message:  fmt.Sprintf("%v", r),
+				Code:     runtime.ErrorCode(r),
+				Message:  msg,
 			}
 			err = f.Bind(program.Source)
 		}
@@ -74,6 +80,10 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 		vm.scopes = vm.scopes[0:0]
 	}
 
+	if len(vm.variables) < program.Variables {
+		vm.variables = make([]interface{}, program.Variables)
+	}
+
 	vm.memoryBudget = MemoryBudget
 	vm.memory = 0
 	vm.ip = 0
@@ -110,6 +120,12 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 		case OpLoadFast:
 			vm.push(env.(map[string]interface{})[program.Constants[arg].(string)])
 
+		case OpStore:
+			vm.variables[arg] = vm.pop()
+
+		case OpLoadVar:
+			vm.push(vm.variables[arg])
+
 		case OpLoadMethod:
 			vm.push(runtime.FetchMethod(env, program.Constants[arg].(*runtime.Method)))
 
@@ -118,6 +134,11 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			a := vm.pop()
 			vm.push(runtime.Fetch(a, b))
 
+		case OpFetchOptional:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.FetchOptional(a, b))
+
 		case OpFetchField:
 			a := vm.pop()
 			vm.push(runtime.FetchField(a, program.Constants[arg].(*runtime.Field)))
@@ -173,6 +194,9 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 
 		case OpJumpIfNil:
 			if runtime.IsNil(vm.current()) {
+				// A typed nil, e.g. of a func or a pointer, becomes untyped,
+				// so a short-circuited chain results in nil of no type.
+				vm.stack[len(vm.stack)-1] = nil
 				vm.ip += arg
 			}
 
@@ -230,6 +254,11 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			a := vm.pop()
 			vm.push(runtime.Divide(a, b))
 
+		case OpDecimalDivide:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.DecimalDivide(a, b))
+
 		case OpModulo:
 			b := vm.pop()
 			a := vm.pop()
@@ -240,6 +269,36 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			a := vm.pop()
 			vm.push(runtime.Exponent(a, b))
 
+		case OpIntDivide:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.IntDivide(a, b))
+
+		case OpBitwiseAnd:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.BitwiseAnd(a, b))
+
+		case OpBitwiseOr:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.BitwiseOr(a, b))
+
+		case OpBitwiseXor:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.BitwiseXor(a, b))
+
+		case OpShiftLeft:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.ShiftLeft(a, b))
+
+		case OpShiftRight:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(runtime.ShiftRight(a, b))
+
 		case OpRange:
 			b := vm.pop()
 			a := vm.pop()
@@ -247,7 +306,7 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			max := runtime.ToInt(b)
 			size := max - min + 1
 			if vm.memory+size >= vm.memoryBudget {
-				panic("memory budget exceeded")
+				panic(errMemoryBudgetExceeded)
 			}
 			vm.push(runtime.MakeRange(min, max))
 			vm.memory += size
@@ -282,12 +341,24 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			a := vm.pop()
 			vm.push(strings.HasSuffix(a.(string), b.(string)))
 
+		case OpConcat:
+			str := runtime.Concat(vm.stack[len(vm.stack)-arg:])
+			vm.stack = vm.stack[:len(vm.stack)-arg]
+			vm.push(str)
+
 		case OpSlice:
 			from := vm.pop()
 			to := vm.pop()
 			node := vm.pop()
 			vm.push(runtime.Slice(node, from, to))
 
+		case OpSliceStep:
+			step := vm.pop()
+			to := vm.pop()
+			from := vm.pop()
+			node := vm.pop()
+			vm.push(runtime.SliceStep(node, from, to, step))
+
 		case OpCall:
 			fn := reflect.ValueOf(vm.pop())
 			size := arg
@@ -308,6 +379,25 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			}
 			vm.push(out[0].Interface())
 
+		case OpCallSpread:
+			fn := reflect.ValueOf(vm.pop())
+			rest := vm.pop()
+			in := make([]reflect.Value, arg-1)
+			for i := arg - 2; i >= 0; i-- {
+				param := vm.pop()
+				if param == nil && reflect.TypeOf(param) == nil {
+					in[i] = reflect.ValueOf(&param).Elem()
+				} else {
+					in[i] = reflect.ValueOf(param)
+				}
+			}
+			in = append(in, runtime.Variadic(fn.Type(), rest)...)
+			out := fn.Call(in)
+			if len(out) == 2 && !runtime.IsNil(out[1]) {
+				panic(out[1].Interface().(error))
+			}
+			vm.push(out[0].Interface())
+
 		case OpCallFast:
 			fn := vm.pop().(func(...interface{}) interface{})
 			size := arg
@@ -322,6 +412,26 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			out := vm.call(fn, arg)
 			vm.push(out)
 
+		case OpCallBuiltin:
+			fn := program.Constants[arg].(*runtime.Builtin)
+			in := make([]interface{}, fn.Arity)
+			for i := fn.Arity - 1; i >= 0; i-- {
+				in[i] = vm.pop()
+			}
+			out, err := fn.Func(in...)
+			if err != nil {
+				panic(err)
+			}
+			vm.push(out)
+			// Arrays and maps created by builtins count against the budget.
+			switch v := reflect.ValueOf(out); v.Kind() {
+			case reflect.Slice, reflect.Map:
+				vm.memory += v.Len()
+				if vm.memory >= vm.memoryBudget {
+					panic(errMemoryBudgetExceeded)
+				}
+			}
+
 		case OpArray:
 			size := vm.pop().(int)
 			array := make([]interface{}, size)
@@ -331,7 +441,25 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			vm.push(array)
 			vm.memory += size
 			if vm.memory >= vm.memoryBudget {
-				panic("memory budget exceeded")
+				panic(errMemoryBudgetExceeded)
+			}
+
+		case OpArraySpread:
+			mask := program.Constants[arg].([]bool)
+			values := vm.stack[len(vm.stack)-len(mask):]
+			array := make([]interface{}, 0, len(mask))
+			for i, value := range values {
+				if mask[i] {
+					array = runtime.Spread(array, value)
+				} else {
+					array = append(array, value)
+				}
+			}
+			vm.stack = vm.stack[:len(vm.stack)-len(mask)]
+			vm.push(array)
+			vm.memory += len(array)
+			if vm.memory >= vm.memoryBudget {
+				panic(errMemoryBudgetExceeded)
 			}
 
 		case OpMap:
@@ -345,7 +473,35 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			vm.push(m)
 			vm.memory += size
 			if vm.memory >= vm.memoryBudget {
-				panic("memory budget exceeded")
+				panic(errMemoryBudgetExceeded)
+			}
+
+		case OpMapSpread:
+			mask := program.Constants[arg].([]bool)
+			size := 0
+			for _, spread := range mask {
+				if spread {
+					size++ // A spread map takes one slot, and a pair takes two.
+				} else {
+					size += 2
+				}
+			}
+			values := vm.stack[len(vm.stack)-size:]
+			m := make(map[string]interface{})
+			for _, spread := range mask {
+				if spread {
+					runtime.SpreadMap(m, values[0])
+					values = values[1:]
+				} else {
+					m[values[0].(string)] = values[1]
+					values = values[2:]
+				}
+			}
+			vm.stack = vm.stack[:len(vm.stack)-size]
+			vm.push(m)
+			vm.memory += len(m)
+			if vm.memory >= vm.memoryBudget {
+				panic(errMemoryBudgetExceeded)
 			}
 
 		case OpLen:
@@ -355,9 +511,17 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			t := arg
 			switch t {
 			case 0:
-				vm.push(runtime.ToInt(vm.pop()))
+				n, err := runtime.ToIntChecked(vm.pop())
+				if err != nil {
+					panic(err)
+				}
+				vm.push(n)
 			case 1:
-				vm.push(runtime.ToInt64(vm.pop()))
+				n, err := runtime.ToInt64Checked(vm.pop())
+				if err != nil {
+					panic(err)
+				}
+				vm.push(n)
 			case 2:
 				vm.push(runtime.ToFloat64(vm.pop()))
 			}
@@ -382,22 +546,47 @@ func (vm *VM) Run(program *Program, env interface{}) (out interface{}, err error
 			scope := vm.Scope()
 			vm.push(scope.Len)
 
+		case OpGetArray:
+			scope := vm.Scope()
+			vm.push(scope.Array.Interface())
+
+		case OpGetAcc:
+			scope := vm.Scope()
+			vm.push(scope.Acc)
+
+		case OpSetAcc:
+			scope := vm.Scope()
+			scope.Acc = vm.pop()
+
 		case OpPointer:
 			scope := vm.Scope()
 			vm.push(scope.Array.Index(scope.It).Interface())
 
+		case OpScopeElement:
+			scope := vm.scopes[arg]
+			vm.push(scope.Array.Index(scope.It).Interface())
+
+		case OpScopeIndex:
+			vm.push(vm.scopes[arg].It)
+
+		case OpScopeAcc:
+			vm.push(vm.scopes[arg].Acc)
+
 		case OpBegin:
 			a := vm.pop()
 			array := reflect.ValueOf(a)
+			if array.Kind() == reflect.Map {
+				array = runtime.Entries(array)
+				vm.memory += array.Len()
+				if vm.memory >= vm.memoryBudget {
+					panic(errMemoryBudgetExceeded)
+				}
+			}
 			vm.scopes = append(vm.scopes, &Scope{
 				Array: array,
 				Len:   array.Len(),
 			})
 
-		case OpAbs:
-			c := vm.current()
-			vm.push(math.Abs(runtime.ToFloat64(c)))
-
 		case OpEnd:
 			vm.scopes = vm.scopes[:len(vm.scopes)-1]
 
@@ -184,6 +184,10 @@ func TestRun_Helpers_Time(t *testing.T) {
 		{a: testTime, b: int64(1), op: "+", wantErr: true},
 		{a: testTime, b: float64(1), op: "+", wantErr: true},
 		{a: testDuration, b: testTime, op: "+", wantErr: false},
+		{a: testDuration, b: testDuration, op: "+", wantErr: false, want: 2 * testDuration},
+		{a: testDuration, b: testDuration, op: "-", wantErr: false, want: time.Duration(0)},
+		{a: testDuration, b: testDuration, op: "<", wantErr: false, want: false},
+		{a: testDuration, b: testDuration, op: ">=", wantErr: false, want: true},
 	}
 
 	for _, tt := range tests {
@@ -230,6 +234,33 @@ func TestRun_MemoryBudget(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestRun_MemoryBudget_builtins(t *testing.T) {
+	input := `map(1..1000, {sortBy(1..1000, {-#})})`
+
+	tree, err := parser.Parse(input)
+	require.NoError(t, err)
+
+	program, err := compiler.Compile(tree, nil)
+	require.NoError(t, err)
+
+	_, err = vm.Run(program, nil)
+	require.Error(t, err)
+}
+
+func TestRun_MemoryBudget_spread(t *testing.T) {
+	input := `reduce(1..2000, {[...#acc, ...1..10]}, [])`
+
+	tree, err := parser.Parse(input)
+	require.NoError(t, err)
+
+	program, err := compiler.Compile(tree, nil)
+	require.NoError(t, err)
+
+	_, err = vm.Run(program, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "memory budget exceeded")
+}
+
 type ErrorEnv struct {
 	InnerEnv InnerEnv
 }